@@ -0,0 +1,155 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+const (
+	// defaultLogTailLines is the number of log lines fetched when the
+	// request does not specify it.
+	defaultLogTailLines = 20
+	// maxLogTailLines is the maximum number of log lines fetched.
+	maxLogTailLines = 200
+	// maxLogLineLength is the maximum length of a log line in bytes.
+	maxLogLineLength = 512
+	// maxLogFilterEvents is the maximum number of events scanned when
+	// filtering log group. The filtering stops once reached.
+	maxLogFilterEvents = 10000
+	// maxLogFilterDuration is the maximum duration of filtering log group.
+	maxLogFilterDuration = 30 * time.Second
+)
+
+// tailCloudWatchLogStream returns the last lines of Amazon CloudWatch Logs
+// log stream.
+func tailCloudWatchLogStream(sess *session.Session, logGroupName, logStreamName string, limit int64) ([]string, error) {
+	cli := cloudwatchlogs.New(sess)
+
+	output, err := cli.GetLogEvents(&cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroupName),
+		LogStreamName: aws.String(logStreamName),
+		Limit:         aws.Int64(limit),
+		StartFromHead: aws.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get amazon cloudwatch logs events: %s", err)
+	}
+
+	var lines []string
+	for _, event := range output.Events {
+		lines = append(lines, formatLogLine(aws.StringValue(event.Message)))
+	}
+	return lines, nil
+}
+
+// filterCloudWatchLogEvents returns the last lines of the events in Amazon
+// CloudWatch Logs log group matching the filter pattern. The filtering is
+// bounded by the number of scanned events and by duration, because the log
+// group may be large. When the bound is reached, the lines read so far are
+// returned.
+func filterCloudWatchLogEvents(sess *session.Session, logGroupName, filterPattern string, startTime time.Time, limit int64) ([]string, error) {
+	cli := cloudwatchlogs.New(sess)
+
+	params := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(logGroupName),
+		FilterPattern: aws.String(filterPattern),
+	}
+	if !startTime.IsZero() {
+		params.StartTime = aws.Int64(startTime.UnixMilli())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maxLogFilterDuration)
+	defer cancel()
+
+	collector := &logEventCollector{limit: limit, maxEvents: maxLogFilterEvents}
+	if err := cli.FilterLogEventsPagesWithContext(ctx, params, func(page *cloudwatchlogs.FilterLogEventsOutput, lastPage bool) bool {
+		return collector.add(page.Events)
+	}); err != nil {
+		if ctx.Err() == nil || len(collector.lines) == 0 {
+			return nil, fmt.Errorf("failed to filter amazon cloudwatch logs events: %s", err)
+		}
+	}
+	return collector.lines, nil
+}
+
+// logEventCollector keeps the last lines of the log events.
+type logEventCollector struct {
+	lines     []string
+	limit     int64
+	scanned   int64
+	maxEvents int64
+}
+
+// add adds the log events to the lines. It returns false when the maximum
+// number of events is reached.
+func (c *logEventCollector) add(events []*cloudwatchlogs.FilteredLogEvent) bool {
+	for _, event := range events {
+		c.lines = append(c.lines, formatLogLine(aws.StringValue(event.Message)))
+	}
+	if int64(len(c.lines)) > c.limit {
+		c.lines = c.lines[int64(len(c.lines))-c.limit:]
+	}
+	c.scanned += int64(len(events))
+	return c.scanned < c.maxEvents
+}
+
+// getLogGroupNameFromArn returns the name of Amazon CloudWatch Logs log group
+// from its ARN, e.g. arn:aws:logs:us-east-1:123456789012:log-group:name:*.
+func getLogGroupNameFromArn(arn string) string {
+	i := strings.Index(arn, ":log-group:")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(arn[i+len(":log-group:"):], ":*")
+}
+
+// logSecretPatterns match the values in log lines that look like secrets.
+// The submatches of each pattern are preserved.
+var logSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?|authorization)"?\s*[:=]\s*"?)(?:bearer\s+|basic\s+)?[^\s",;&]+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`()\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`(://[^:/\s]+:)[^@/\s]+(@)`),
+}
+
+// redactLogLine replaces the values in the log line that look like secrets.
+func redactLogLine(s string) string {
+	for _, p := range logSecretPatterns {
+		s = p.ReplaceAllString(s, "${1}[REDACTED]${2}")
+	}
+	return s
+}
+
+// formatLogLine trims, redacts and truncates the log line.
+func formatLogLine(s string) string {
+	return truncateText(redactLogLine(strings.TrimRight(s, "\r\n")), maxLogLineLength)
+}
+
+// getLogTailLines returns the number of log lines to fetch for the request.
+func getLogTailLines(req *PluginRequest) int64 {
+	if req.LogTailLines > 0 {
+		return req.LogTailLines
+	}
+	return defaultLogTailLines
+}
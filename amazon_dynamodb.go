@@ -0,0 +1,348 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfDynamoDBTableExists checks whether a particular Amazon DynamoDB table exists.
+func (ex *ExecutorPlugin) CheckIfDynamoDBTableExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := dynamodb.New(sess)
+
+	params := &dynamodb.DescribeTableInput{
+		TableName: aws.String(req.TableName),
+	}
+
+	output, err := cli.DescribeTable(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon dynamodb table: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon dynamodb table check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// PutDynamoDBItem writes the item of the request to Amazon DynamoDB table.
+func (ex *ExecutorPlugin) PutDynamoDBItem(req *PluginRequest) *PluginResponse {
+	item, err := dynamodbattribute.MarshalMap(req.Item)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to build amazon dynamodb item: %s", err),
+			Status:         2,
+		}
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := dynamodb.New(sess)
+
+	params := &dynamodb.PutItemInput{
+		TableName: aws.String(req.TableName),
+		Item:      item,
+	}
+	if req.ConditionExpression != "" {
+		params.ConditionExpression = aws.String(req.ConditionExpression)
+	}
+	if err := req.addDynamoDBExpressionAttributes(&params.ExpressionAttributeNames, &params.ExpressionAttributeValues); err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	if _, err := cli.PutItem(params); err != nil {
+		return buildDynamoDBErrorResponse("put", err)
+	}
+
+	ex.Logger.Info("put amazon dynamodb item",
+		zap.String("plugin_name", app.Name),
+		zap.String("table_name", req.TableName),
+	)
+
+	return buildDynamoDBItemResponse("put", item)
+}
+
+// UpdateDynamoDBItem updates the item of Amazon DynamoDB table with the
+// update expression of the request.
+func (ex *ExecutorPlugin) UpdateDynamoDBItem(req *PluginRequest) *PluginResponse {
+	key, err := dynamodbattribute.MarshalMap(req.ItemKey)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to build amazon dynamodb item key: %s", err),
+			Status:         2,
+		}
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := dynamodb.New(sess)
+
+	params := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(req.TableName),
+		Key:              key,
+		UpdateExpression: aws.String(req.UpdateExpression),
+		ReturnValues:     aws.String(dynamodb.ReturnValueAllNew),
+	}
+	if req.ConditionExpression != "" {
+		params.ConditionExpression = aws.String(req.ConditionExpression)
+	}
+	if err := req.addDynamoDBExpressionAttributes(&params.ExpressionAttributeNames, &params.ExpressionAttributeValues); err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	output, err := cli.UpdateItem(params)
+	if err != nil {
+		return buildDynamoDBErrorResponse("update", err)
+	}
+
+	ex.Logger.Info("updated amazon dynamodb item",
+		zap.String("plugin_name", app.Name),
+		zap.String("table_name", req.TableName),
+	)
+
+	return buildDynamoDBItemResponse("updated", output.Attributes)
+}
+
+// GetDynamoDBItem reads the item of Amazon DynamoDB table.
+func (ex *ExecutorPlugin) GetDynamoDBItem(req *PluginRequest) *PluginResponse {
+	item, err := getDynamoDBItem(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	if len(item) == 0 {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("amazon dynamodb item not found in table %s", req.TableName),
+			Status:         2,
+		}
+	}
+
+	return buildDynamoDBItemResponse("got", item)
+}
+
+// WaitForDynamoDBItem checks whether the attribute of Amazon DynamoDB item
+// has the expected value. When the expected value is not set, it checks
+// whether the attribute exists. The node remains running until the attribute
+// matches or the wait timeout expires.
+func (ex *ExecutorPlugin) WaitForDynamoDBItem(req *PluginRequest, workflowID string) *PluginResponse {
+	wf, exists := ex.getWorkflow(req, workflowID)
+	if !exists {
+		wf = &PluginWorkflow{
+			ID:        req.ResourceArn,
+			StartedAt: time.Now().UTC(),
+		}
+		ex.setWorkflow(req, workflowID, wf)
+		ex.Logger.Info("started waiting for amazon dynamodb item",
+			zap.String("plugin_name", app.Name),
+			zap.String("table_name", req.TableName),
+			zap.String("attribute_name", req.WaitAttributeName),
+		)
+	}
+
+	item, err := getDynamoDBItem(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	matched, err := matchDynamoDBAttribute(item, req.WaitAttributeName, req.WaitAttributeValue)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	if matched {
+		return buildDynamoDBItemResponse("matched", item)
+	}
+
+	if req.WaitTimeout > 0 && time.Since(wf.StartedAt) > time.Duration(req.WaitTimeout)*time.Second {
+		return &PluginResponse{
+			ExecutionError:  fmt.Errorf("timed out after %ds waiting for amazon dynamodb item attribute %s in table %s", req.WaitTimeout, req.WaitAttributeName, req.TableName),
+			Status:          2,
+			FailureCategory: FailureTimeout,
+		}
+	}
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("waiting for amazon dynamodb item attribute %s in table %s", req.WaitAttributeName, req.TableName),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 30 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// getDynamoDBItem returns the item of Amazon DynamoDB table with strongly
+// consistent read. It returns an empty item when the item does not exist.
+func getDynamoDBItem(req *PluginRequest) (map[string]*dynamodb.AttributeValue, error) {
+	key, err := dynamodbattribute.MarshalMap(req.ItemKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build amazon dynamodb item key: %s", err)
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session: %s", err)
+	}
+
+	cli := dynamodb.New(sess)
+
+	output, err := cli.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(req.TableName),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get amazon dynamodb item: %s", err)
+	}
+	return output.Item, nil
+}
+
+// matchDynamoDBAttribute checks whether the attribute of the item has the
+// expected value. The values are compared by their JSON representation.
+func matchDynamoDBAttribute(item map[string]*dynamodb.AttributeValue, name string, want interface{}) (bool, error) {
+	av, exists := item[name]
+	if !exists {
+		return false, nil
+	}
+	if want == nil {
+		return true, nil
+	}
+	var got interface{}
+	if err := dynamodbattribute.Unmarshal(av, &got); err != nil {
+		return false, fmt.Errorf("failed to decode amazon dynamodb attribute %s: %s", name, err)
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode amazon dynamodb attribute %s: %s", name, err)
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode expected value of attribute %s: %s", name, err)
+	}
+	return string(gotJSON) == string(wantJSON), nil
+}
+
+// addDynamoDBExpressionAttributes adds the expression attribute names and
+// values of the request to the input of the API call.
+func (req *PluginRequest) addDynamoDBExpressionAttributes(names *map[string]*string, values *map[string]*dynamodb.AttributeValue) error {
+	if len(req.ExpressionAttributeNames) > 0 {
+		*names = aws.StringMap(req.ExpressionAttributeNames)
+	}
+	if len(req.ExpressionAttributeValues) > 0 {
+		m, err := dynamodbattribute.MarshalMap(req.ExpressionAttributeValues)
+		if err != nil {
+			return fmt.Errorf("failed to build amazon dynamodb expression attribute values: %s", err)
+		}
+		*values = m
+	}
+	return nil
+}
+
+// buildDynamoDBItemResponse returns the response with the item as JSON output.
+func buildDynamoDBItemResponse(verb string, item map[string]*dynamodb.AttributeValue) *PluginResponse {
+	m := make(map[string]interface{})
+	if err := dynamodbattribute.UnmarshalMap(item, &m); err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to decode amazon dynamodb item: %s", err),
+			Status:         2,
+		}
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon dynamodb item: %s", err),
+			Status:         2,
+		}
+	}
+	return &PluginResponse{
+		Message: fmt.Sprintf("%s amazon dynamodb item", verb),
+		Status:  1,
+		Outputs: map[string]string{
+			"item": string(b),
+		},
+	}
+}
+
+// buildDynamoDBErrorResponse returns the response for the failed write.
+// The failed condition check is reported separately from other errors,
+// because the write may succeed when retried, e.g. once the lock is released.
+func buildDynamoDBErrorResponse(verb string, err error) *PluginResponse {
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return &PluginResponse{
+			ExecutionError:  fmt.Errorf("failed to %s amazon dynamodb item: condition check failed: %s", verb, err),
+			Status:          2,
+			FailureCategory: FailureConditionFailed,
+		}
+	}
+	return &PluginResponse{
+		ExecutionError: fmt.Errorf("failed to %s amazon dynamodb item: %s", verb, err),
+		Status:         2,
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchDynamoDBAttribute(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"job_id":   {S: aws.String("nightly-load")},
+		"status":   {S: aws.String("READY")},
+		"attempts": {N: aws.String("3")},
+	}
+
+	var testcases = []struct {
+		name  string
+		attr  string
+		value interface{}
+		want  bool
+	}{
+		{
+			name:  "test matching string attribute",
+			attr:  "status",
+			value: "READY",
+			want:  true,
+		},
+		{
+			name:  "test non-matching string attribute",
+			attr:  "status",
+			value: "PENDING",
+			want:  false,
+		},
+		{
+			name:  "test matching number attribute",
+			attr:  "attempts",
+			value: float64(3),
+			want:  true,
+		},
+		{
+			name: "test existing attribute without expected value",
+			attr: "status",
+			want: true,
+		},
+		{
+			name:  "test missing attribute",
+			attr:  "finished_at",
+			value: "2023-10-01T00:00:00Z",
+			want:  false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := matchDynamoDBAttribute(item, tc.attr, tc.value)
+			if err != nil {
+				t.Fatalf("test name: %s, unexpected error: %v", tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestBuildDynamoDBErrorResponse(t *testing.T) {
+	var testcases = []struct {
+		name string
+		err  error
+		want map[string]interface{}
+	}{
+		{
+			name: "test failed condition check",
+			err:  awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil),
+			want: map[string]interface{}{
+				"error":            "failed to put amazon dynamodb item: condition check failed: ConditionalCheckFailedException: The conditional request failed",
+				"failure_category": FailureConditionFailed,
+			},
+		},
+		{
+			name: "test other error",
+			err:  awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Requested resource not found", nil),
+			want: map[string]interface{}{
+				"error":            "failed to put amazon dynamodb item: ResourceNotFoundException: Requested resource not found",
+				"failure_category": FailureResourceMissing,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := buildDynamoDBErrorResponse("put", tc.err)
+			got := map[string]interface{}{
+				"error":            resp.ExecutionError.Error(),
+				"failure_category": classifyFailure(resp),
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfEC2InstancesExist checks whether the Amazon EC2 instances selected
+// by their IDs or tags exist.
+func (ex *ExecutorPlugin) CheckIfEC2InstancesExist(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ec2.New(sess)
+
+	states, err := describeEC2InstanceStates(cli, req.InstanceIDs, req.TargetTags)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	if len(states) == 0 {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("amazon ec2 instances not found"),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(states)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon ec2 instances check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartEC2InstancesOperation starts, stops or reboots Amazon EC2 instances.
+func (ex *ExecutorPlugin) StartEC2InstancesOperation(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ec2.New(sess)
+
+	instanceIDs := req.InstanceIDs
+	if len(instanceIDs) == 0 {
+		states, err := describeEC2InstanceStates(cli, nil, req.TargetTags)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: err,
+				Status:         2,
+			}
+		}
+		for instanceID, state := range states {
+			if state == ec2.InstanceStateNameTerminated || state == ec2.InstanceStateNameShuttingDown {
+				continue
+			}
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+		sort.Strings(instanceIDs)
+	}
+
+	if len(instanceIDs) == 0 {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("no amazon ec2 instances matched target tags"),
+			Status:         2,
+		}
+	}
+
+	switch req.Operation {
+	case "start":
+		_, err = cli.StartInstances(&ec2.StartInstancesInput{
+			InstanceIds: aws.StringSlice(instanceIDs),
+		})
+	case "stop":
+		_, err = cli.StopInstances(&ec2.StopInstancesInput{
+			InstanceIds: aws.StringSlice(instanceIDs),
+		})
+	case "reboot":
+		_, err = cli.RebootInstances(&ec2.RebootInstancesInput{
+			InstanceIds: aws.StringSlice(instanceIDs),
+		})
+	}
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to %s amazon ec2 instances: %s", req.Operation, err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("started amazon ec2 instances operation",
+		zap.String("plugin_name", app.Name),
+		zap.String("operation", req.Operation),
+		zap.Strings("instance_ids", instanceIDs),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: strings.Join(instanceIDs, ","),
+	})
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("started %s of %d amazon ec2 instances", req.Operation, len(instanceIDs)),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 15 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// CheckEC2InstancesOperation checks whether Amazon EC2 instances reached the
+// target state of the operation.
+func (ex *ExecutorPlugin) CheckEC2InstancesOperation(req *PluginRequest, instanceIDs string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ec2.New(sess)
+
+	states, err := describeEC2InstanceStates(cli, strings.Split(instanceIDs, ","), nil)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	targetState := getEC2TargetState(req.Operation)
+
+	// The rebooted instances remain running, so the reboot completes when
+	// the instances pass the instance and system status checks.
+	var statusChecks map[string]bool
+	if req.Operation == "reboot" {
+		statusChecks, err = describeEC2InstanceStatusChecks(cli, strings.Split(instanceIDs, ","))
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: err,
+				Status:         2,
+			}
+		}
+	}
+
+	ex.Logger.Info("checking amazon ec2 instances operation",
+		zap.String("plugin_name", app.Name),
+		zap.String("operation", req.Operation),
+		zap.Any("instance_states", states),
+	)
+
+	b, err := json.Marshal(states)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon ec2 instance states: %s", err),
+			Status:         2,
+		}
+	}
+
+	outputs := map[string]string{
+		"instance_states": string(b),
+	}
+
+	summary := summarizeEC2InstanceStates(states)
+	progress := getEC2InstancesProgress(strings.Split(instanceIDs, ","), states, targetState, statusChecks)
+
+	if len(progress.missing) > 0 {
+		return &PluginResponse{
+			ExecutionError:  fmt.Errorf("amazon ec2 instances do not exist: %s", strings.Join(progress.missing, ", ")),
+			Status:          2,
+			FailureCategory: FailureResourceMissing,
+			Outputs:         outputs,
+		}
+	}
+
+	if len(progress.failed) > 0 {
+		return &PluginResponse{
+			Message: fmt.Sprintf("amazon ec2 instances failed to reach %s state: %s", targetState, summary),
+			Status:  4,
+			Outputs: outputs,
+		}
+	}
+
+	if len(progress.pending) > 0 {
+		msg := fmt.Sprintf("waiting for %d amazon ec2 instances to reach %s state: %s", len(progress.pending), targetState, summary)
+		if req.Operation == "reboot" {
+			msg = fmt.Sprintf("waiting for %d amazon ec2 instances to pass status checks: %s", len(progress.pending), summary)
+		}
+		return &PluginResponse{
+			Message:       msg,
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 15 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+
+	return &PluginResponse{
+		Message: fmt.Sprintf("amazon ec2 instances reached %s state: %s", targetState, summary),
+		Status:  1,
+		Outputs: outputs,
+	}
+}
+
+// getEC2TargetState returns the state Amazon EC2 instances reach when the
+// operation completes.
+func getEC2TargetState(operation string) string {
+	if operation == "stop" {
+		return ec2.InstanceStateNameStopped
+	}
+	return ec2.InstanceStateNameRunning
+}
+
+// ec2InstancesProgress holds the instances of the operation grouped by
+// their progress towards the target state.
+type ec2InstancesProgress struct {
+	missing []string
+	failed  []string
+	pending []string
+}
+
+// getEC2InstancesProgress compares the states of the instances with the
+// target state. When the status checks are provided, the instances in the
+// target state must also pass the status checks.
+func getEC2InstancesProgress(instanceIDs []string, states map[string]string, targetState string, statusChecks map[string]bool) *ec2InstancesProgress {
+	progress := &ec2InstancesProgress{}
+	for _, instanceID := range instanceIDs {
+		state, exists := states[instanceID]
+		switch {
+		case !exists:
+			progress.missing = append(progress.missing, instanceID)
+		case state == targetState:
+			if statusChecks != nil && !statusChecks[instanceID] {
+				progress.pending = append(progress.pending, instanceID)
+			}
+		case state == ec2.InstanceStateNameTerminated, state == ec2.InstanceStateNameShuttingDown:
+			progress.failed = append(progress.failed, instanceID)
+		default:
+			progress.pending = append(progress.pending, instanceID)
+		}
+	}
+	return progress
+}
+
+// describeEC2InstanceStatusChecks returns whether Amazon EC2 instances pass
+// both the instance and the system status checks.
+func describeEC2InstanceStatusChecks(cli *ec2.EC2, instanceIDs []string) (map[string]bool, error) {
+	statusChecks := make(map[string]bool)
+	if err := cli.DescribeInstanceStatusPages(&ec2.DescribeInstanceStatusInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	}, func(page *ec2.DescribeInstanceStatusOutput, lastPage bool) bool {
+		for _, status := range page.InstanceStatuses {
+			statusChecks[aws.StringValue(status.InstanceId)] = isEC2StatusCheckPassed(status.InstanceStatus) &&
+				isEC2StatusCheckPassed(status.SystemStatus)
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to describe amazon ec2 instance status: %s", err)
+	}
+	return statusChecks, nil
+}
+
+// isEC2StatusCheckPassed checks whether the status check summary is ok.
+func isEC2StatusCheckPassed(summary *ec2.InstanceStatusSummary) bool {
+	return summary != nil && aws.StringValue(summary.Status) == ec2.SummaryStatusOk
+}
+
+// describeEC2InstanceStates returns the states of Amazon EC2 instances
+// selected by their IDs or tags.
+func describeEC2InstanceStates(cli *ec2.EC2, instanceIDs []string, tags map[string]string) (map[string]string, error) {
+	params := &ec2.DescribeInstancesInput{}
+	if len(instanceIDs) > 0 {
+		params.InstanceIds = aws.StringSlice(instanceIDs)
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		params.Filters = append(params.Filters, &ec2.Filter{
+			Name:   aws.String("tag:" + k),
+			Values: aws.StringSlice([]string{tags[k]}),
+		})
+	}
+
+	states := make(map[string]string)
+	if err := cli.DescribeInstancesPages(params, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				states[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.State.Name)
+			}
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to describe amazon ec2 instances: %s", err)
+	}
+	return states, nil
+}
+
+// summarizeEC2InstanceStates returns the list of instances and their states.
+func summarizeEC2InstanceStates(states map[string]string) string {
+	keys := make([]string, 0, len(states))
+	for k := range states {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var entries []string
+	for _, k := range keys {
+		entries = append(entries, fmt.Sprintf("%s:%s", k, states[k]))
+	}
+	return strings.Join(entries, ", ")
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetEC2InstancesProgress(t *testing.T) {
+	var testcases = []struct {
+		name         string
+		operation    string
+		instanceIDs  []string
+		states       map[string]string
+		statusChecks map[string]bool
+		want         *ec2InstancesProgress
+	}{
+		{
+			name:        "test start instances running",
+			operation:   "start",
+			instanceIDs: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			states: map[string]string{
+				"i-0a1b2c3d": "running",
+				"i-0e1f2a3b": "running",
+			},
+			want: &ec2InstancesProgress{},
+		},
+		{
+			name:        "test start instances pending",
+			operation:   "start",
+			instanceIDs: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			states: map[string]string{
+				"i-0a1b2c3d": "running",
+				"i-0e1f2a3b": "pending",
+			},
+			want: &ec2InstancesProgress{
+				pending: []string{"i-0e1f2a3b"},
+			},
+		},
+		{
+			name:        "test stop instances stopping and stopped",
+			operation:   "stop",
+			instanceIDs: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			states: map[string]string{
+				"i-0a1b2c3d": "stopped",
+				"i-0e1f2a3b": "stopping",
+			},
+			want: &ec2InstancesProgress{
+				pending: []string{"i-0e1f2a3b"},
+			},
+		},
+		{
+			name:        "test stop instances terminated",
+			operation:   "stop",
+			instanceIDs: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			states: map[string]string{
+				"i-0a1b2c3d": "shutting-down",
+				"i-0e1f2a3b": "terminated",
+			},
+			want: &ec2InstancesProgress{
+				failed: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			},
+		},
+		{
+			name:        "test start instances missing",
+			operation:   "start",
+			instanceIDs: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			states: map[string]string{
+				"i-0a1b2c3d": "running",
+			},
+			want: &ec2InstancesProgress{
+				missing: []string{"i-0e1f2a3b"},
+			},
+		},
+		{
+			name:        "test reboot instances without passed status checks",
+			operation:   "reboot",
+			instanceIDs: []string{"i-0a1b2c3d", "i-0e1f2a3b"},
+			states: map[string]string{
+				"i-0a1b2c3d": "running",
+				"i-0e1f2a3b": "running",
+			},
+			statusChecks: map[string]bool{
+				"i-0a1b2c3d": true,
+				"i-0e1f2a3b": false,
+			},
+			want: &ec2InstancesProgress{
+				pending: []string{"i-0e1f2a3b"},
+			},
+		},
+		{
+			name:        "test reboot instances with passed status checks",
+			operation:   "reboot",
+			instanceIDs: []string{"i-0a1b2c3d"},
+			states: map[string]string{
+				"i-0a1b2c3d": "running",
+			},
+			statusChecks: map[string]bool{
+				"i-0a1b2c3d": true,
+			},
+			want: &ec2InstancesProgress{},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getEC2InstancesProgress(tc.instanceIDs, tc.states, getEC2TargetState(tc.operation), tc.statusChecks)
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(ec2InstancesProgress{})); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"go.uber.org/zap"
+)
+
+// eventBridgeMaxBatchSize is the maximum number of entries in PutEvents call.
+const eventBridgeMaxBatchSize = 10
+
+// CheckIfEventBusExists checks whether a particular Amazon EventBridge event bus exists.
+func (ex *ExecutorPlugin) CheckIfEventBusExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := eventbridge.New(sess)
+
+	params := &eventbridge.DescribeEventBusInput{
+		Name: aws.String(req.EventBusName),
+	}
+
+	output, err := cli.DescribeEventBus(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon eventbridge event bus: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon eventbridge event bus check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// PutEventBridgeEvents sends one or more events to Amazon EventBridge event bus.
+// The event detail is built from the parameters of the request, or from each
+// entry of the event batch.
+func (ex *ExecutorPlugin) PutEventBridgeEvents(req *PluginRequest) *PluginResponse {
+	details := req.EventBatch
+	if len(details) == 0 {
+		details = []map[string]interface{}{req.Parameters}
+	}
+
+	var entries []*eventbridge.PutEventsRequestEntry
+	for _, detail := range details {
+		if detail == nil {
+			detail = make(map[string]interface{})
+		}
+		b, err := json.Marshal(detail)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to build amazon eventbridge event detail: %s", err),
+				Status:         2,
+			}
+		}
+		entries = append(entries, &eventbridge.PutEventsRequestEntry{
+			EventBusName: aws.String(req.EventBusName),
+			Source:       aws.String(req.EventSource),
+			DetailType:   aws.String(req.EventDetailType),
+			Detail:       aws.String(string(b)),
+		})
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := eventbridge.New(sess)
+
+	var eventIDs []string
+	var failures []string
+
+	for start := 0; start < len(entries); start += eventBridgeMaxBatchSize {
+		end := start + eventBridgeMaxBatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		output, err := cli.PutEvents(&eventbridge.PutEventsInput{
+			Entries: entries[start:end],
+		})
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to put amazon eventbridge events: %s", err),
+				Status:         2,
+			}
+		}
+
+		eventIDs, failures = appendEventBridgeResults(eventIDs, failures, start, output.Entries)
+	}
+
+	ex.Logger.Info("put amazon eventbridge events",
+		zap.String("plugin_name", app.Name),
+		zap.String("event_bus_name", req.EventBusName),
+		zap.Int("sent_count", len(eventIDs)),
+		zap.Int("failed_count", len(failures)),
+	)
+
+	outputs := map[string]string{
+		"event_ids":    strings.Join(eventIDs, ","),
+		"sent_count":   strconv.Itoa(len(eventIDs)),
+		"failed_count": strconv.Itoa(len(failures)),
+	}
+
+	if len(failures) > 0 {
+		return &PluginResponse{
+			Message: fmt.Sprintf("failed to put %d of %d amazon eventbridge events: %s",
+				len(failures), len(entries), strings.Join(failures, "; ")),
+			Status:  2,
+			Outputs: outputs,
+		}
+	}
+
+	return &PluginResponse{
+		Message: fmt.Sprintf("put %d amazon eventbridge events", len(eventIDs)),
+		Status:  1,
+		Outputs: outputs,
+	}
+}
+
+// appendEventBridgeResults appends the IDs of the events put successfully and
+// the failures of the batch starting at the index of the request entries.
+// The result entries are in the same order as the request entries.
+func appendEventBridgeResults(eventIDs, failures []string, start int, entries []*eventbridge.PutEventsResultEntry) ([]string, []string) {
+	for i, entry := range entries {
+		if entry.ErrorCode != nil {
+			failures = append(failures, fmt.Sprintf("entry %d: %s (%s)",
+				start+i, aws.StringValue(entry.ErrorCode), aws.StringValue(entry.ErrorMessage)))
+			continue
+		}
+		eventIDs = append(eventIDs, aws.StringValue(entry.EventId))
+	}
+	return eventIDs, failures
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAppendEventBridgeResults(t *testing.T) {
+	var testcases = []struct {
+		name         string
+		eventIDs     []string
+		failures     []string
+		start        int
+		entries      []*eventbridge.PutEventsResultEntry
+		wantEventIDs []string
+		wantFailures []string
+	}{
+		{
+			name:  "test all entries succeeded",
+			start: 0,
+			entries: []*eventbridge.PutEventsResultEntry{
+				{EventId: aws.String("e-1")},
+				{EventId: aws.String("e-2")},
+			},
+			wantEventIDs: []string{"e-1", "e-2"},
+		},
+		{
+			name:     "test failed entries in second batch",
+			eventIDs: []string{"e-1", "e-2"},
+			start:    10,
+			entries: []*eventbridge.PutEventsResultEntry{
+				{EventId: aws.String("e-11")},
+				{ErrorCode: aws.String("ThrottlingException"), ErrorMessage: aws.String("Rate exceeded")},
+				{EventId: aws.String("e-13")},
+				{ErrorCode: aws.String("InternalFailure")},
+			},
+			wantEventIDs: []string{"e-1", "e-2", "e-11", "e-13"},
+			wantFailures: []string{
+				"entry 11: ThrottlingException (Rate exceeded)",
+				"entry 13: InternalFailure ()",
+			},
+		},
+		{
+			name:     "test all entries failed after earlier failures",
+			failures: []string{"entry 0: InternalFailure ()"},
+			start:    10,
+			entries: []*eventbridge.PutEventsResultEntry{
+				{ErrorCode: aws.String("InternalFailure")},
+			},
+			wantFailures: []string{
+				"entry 0: InternalFailure ()",
+				"entry 10: InternalFailure ()",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventIDs, failures := appendEventBridgeResults(tc.eventIDs, tc.failures, tc.start, tc.entries)
+			if diff := cmp.Diff(tc.wantEventIDs, eventIDs); diff != "" {
+				t.Fatalf("test name: %s, unexpected event ids (-want +got):\n%s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.wantFailures, failures); diff != "" {
+				t.Fatalf("test name: %s, unexpected failures (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
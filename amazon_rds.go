@@ -0,0 +1,301 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfRDSResourceExists checks whether a particular Amazon RDS DB instance
+// or cluster exists.
+func (ex *ExecutorPlugin) CheckIfRDSResourceExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := rds.New(sess)
+
+	var output interface{}
+	if req.Kind == "cluster" {
+		output, err = cli.DescribeDBClusters(&rds.DescribeDBClustersInput{
+			DBClusterIdentifier: aws.String(req.DBIdentifier),
+		})
+	} else {
+		output, err = cli.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: aws.String(req.DBIdentifier),
+		})
+	}
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon rds db %s: %s", req.Kind, err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon rds db %s check response: %s", req.Kind, err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartRDSOperation starts or stops Amazon RDS DB instance or cluster, or
+// creates its snapshot.
+func (ex *ExecutorPlugin) StartRDSOperation(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := rds.New(sess)
+
+	// The identifier of the resource polled until the operation completes.
+	resourceID := req.DBIdentifier
+
+	switch {
+	case req.Kind == "cluster" && req.Operation == "start":
+		_, err = cli.StartDBCluster(&rds.StartDBClusterInput{
+			DBClusterIdentifier: aws.String(req.DBIdentifier),
+		})
+	case req.Kind == "cluster" && req.Operation == "stop":
+		_, err = cli.StopDBCluster(&rds.StopDBClusterInput{
+			DBClusterIdentifier: aws.String(req.DBIdentifier),
+		})
+	case req.Kind == "cluster" && req.Operation == "snapshot":
+		resourceID = getRDSSnapshotIdentifier(req)
+		_, err = cli.CreateDBClusterSnapshot(&rds.CreateDBClusterSnapshotInput{
+			DBClusterIdentifier:         aws.String(req.DBIdentifier),
+			DBClusterSnapshotIdentifier: aws.String(resourceID),
+		})
+	case req.Operation == "start":
+		_, err = cli.StartDBInstance(&rds.StartDBInstanceInput{
+			DBInstanceIdentifier: aws.String(req.DBIdentifier),
+		})
+	case req.Operation == "stop":
+		_, err = cli.StopDBInstance(&rds.StopDBInstanceInput{
+			DBInstanceIdentifier: aws.String(req.DBIdentifier),
+		})
+	case req.Operation == "snapshot":
+		resourceID = getRDSSnapshotIdentifier(req)
+		_, err = cli.CreateDBSnapshot(&rds.CreateDBSnapshotInput{
+			DBInstanceIdentifier: aws.String(req.DBIdentifier),
+			DBSnapshotIdentifier: aws.String(resourceID),
+		})
+	}
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to %s amazon rds db %s: %s", req.Operation, req.Kind, err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("started amazon rds operation",
+		zap.String("plugin_name", app.Name),
+		zap.String("operation", req.Operation),
+		zap.String("kind", req.Kind),
+		zap.String("db_identifier", req.DBIdentifier),
+		zap.String("resource_id", resourceID),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: resourceID,
+	})
+
+	outputs := map[string]string{}
+	if req.Operation == "snapshot" {
+		outputs["snapshot_identifier"] = resourceID
+	}
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("started %s of amazon rds db %s %s", req.Operation, req.Kind, req.DBIdentifier),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 30 * time.Second,
+		},
+		Status:  3,
+		Outputs: outputs,
+	}
+}
+
+// CheckRDSOperation checks whether Amazon RDS DB instance, cluster or
+// snapshot reached the target status of the operation.
+func (ex *ExecutorPlugin) CheckRDSOperation(req *PluginRequest, resourceID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := rds.New(sess)
+
+	var status, arn, resourceName string
+
+	switch {
+	case req.Kind == "cluster" && req.Operation == "snapshot":
+		resourceName = "db cluster snapshot"
+		output, err := cli.DescribeDBClusterSnapshots(&rds.DescribeDBClusterSnapshotsInput{
+			DBClusterSnapshotIdentifier: aws.String(resourceID),
+		})
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to describe amazon rds %s: %s", resourceName, err),
+				Status:         2,
+			}
+		}
+		if len(output.DBClusterSnapshots) > 0 {
+			status = aws.StringValue(output.DBClusterSnapshots[0].Status)
+			arn = aws.StringValue(output.DBClusterSnapshots[0].DBClusterSnapshotArn)
+		}
+	case req.Kind == "cluster":
+		resourceName = "db cluster"
+		output, err := cli.DescribeDBClusters(&rds.DescribeDBClustersInput{
+			DBClusterIdentifier: aws.String(resourceID),
+		})
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to describe amazon rds %s: %s", resourceName, err),
+				Status:         2,
+			}
+		}
+		if len(output.DBClusters) > 0 {
+			status = aws.StringValue(output.DBClusters[0].Status)
+			arn = aws.StringValue(output.DBClusters[0].DBClusterArn)
+		}
+	case req.Operation == "snapshot":
+		resourceName = "db snapshot"
+		output, err := cli.DescribeDBSnapshots(&rds.DescribeDBSnapshotsInput{
+			DBSnapshotIdentifier: aws.String(resourceID),
+		})
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to describe amazon rds %s: %s", resourceName, err),
+				Status:         2,
+			}
+		}
+		if len(output.DBSnapshots) > 0 {
+			status = aws.StringValue(output.DBSnapshots[0].Status)
+			arn = aws.StringValue(output.DBSnapshots[0].DBSnapshotArn)
+		}
+	default:
+		resourceName = "db instance"
+		output, err := cli.DescribeDBInstances(&rds.DescribeDBInstancesInput{
+			DBInstanceIdentifier: aws.String(resourceID),
+		})
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to describe amazon rds %s: %s", resourceName, err),
+				Status:         2,
+			}
+		}
+		if len(output.DBInstances) > 0 {
+			status = aws.StringValue(output.DBInstances[0].DBInstanceStatus)
+			arn = aws.StringValue(output.DBInstances[0].DBInstanceArn)
+		}
+	}
+
+	if status == "" {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("amazon rds %s %q not found", resourceName, resourceID),
+			Status:         2,
+		}
+	}
+
+	targetStatus := "available"
+	if req.Operation == "stop" {
+		targetStatus = "stopped"
+	}
+
+	ex.Logger.Info("checking amazon rds operation",
+		zap.String("plugin_name", app.Name),
+		zap.String("operation", req.Operation),
+		zap.String("resource_id", resourceID),
+		zap.String("status", status),
+	)
+
+	outputs := map[string]string{
+		"resource_arn": arn,
+		"status":       status,
+	}
+	if req.Operation == "snapshot" {
+		outputs["snapshot_identifier"] = resourceID
+	}
+
+	switch {
+	case status == targetStatus:
+		return &PluginResponse{
+			Message: fmt.Sprintf("amazon rds %s %s is %s", resourceName, resourceID, status),
+			Status:  1,
+			Outputs: outputs,
+		}
+	case isRDSFailedStatus(status):
+		return &PluginResponse{
+			Message: fmt.Sprintf("amazon rds %s %s failed to reach %s status: %s", resourceName, resourceID, targetStatus, status),
+			Status:  4,
+			Outputs: outputs,
+		}
+	default:
+		return &PluginResponse{
+			Message:       fmt.Sprintf("amazon rds %s %s is %s", resourceName, resourceID, status),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 30 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+}
+
+// getRDSSnapshotIdentifier returns the identifier of the snapshot from the
+// request or derives it from the identifier of the DB instance or cluster.
+func getRDSSnapshotIdentifier(req *PluginRequest) string {
+	if req.SnapshotIdentifier != "" {
+		return req.SnapshotIdentifier
+	}
+	return fmt.Sprintf("%s-%s", req.DBIdentifier, time.Now().UTC().Format("20060102-150405"))
+}
+
+// isRDSFailedStatus checks whether the status of Amazon RDS resource means
+// that it would not reach the target status without intervention.
+func isRDSFailedStatus(status string) bool {
+	switch status {
+	case "failed", "deleting", "storage-full", "inaccessible-encryption-credentials":
+		return true
+	}
+	return strings.HasPrefix(status, "incompatible-")
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsRDSFailedStatus(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		status string
+		want   bool
+	}{
+		{name: "test available status", status: "available", want: false},
+		{name: "test stopping status", status: "stopping", want: false},
+		{name: "test creating status", status: "creating", want: false},
+		{name: "test failed status", status: "failed", want: true},
+		{name: "test deleting status", status: "deleting", want: true},
+		{name: "test storage full status", status: "storage-full", want: true},
+		{name: "test inaccessible encryption credentials status", status: "inaccessible-encryption-credentials", want: true},
+		{name: "test incompatible parameters status", status: "incompatible-parameters", want: true},
+		{name: "test incompatible restore status", status: "incompatible-restore", want: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := isRDSFailedStatus(tc.status)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/redshiftdataapiservice"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfRedshiftDatabaseExists checks whether a particular Amazon Redshift database
+// is reachable via the Data API with the provided credentials.
+func (ex *ExecutorPlugin) CheckIfRedshiftDatabaseExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	rd := redshiftdataapiservice.New(sess)
+
+	params := &redshiftdataapiservice.ListDatabasesInput{
+		Database: aws.String(req.DatabaseName),
+	}
+	if req.ClusterIdentifier != "" {
+		params.ClusterIdentifier = aws.String(req.ClusterIdentifier)
+	}
+	if req.WorkgroupName != "" {
+		params.WorkgroupName = aws.String(req.WorkgroupName)
+	}
+	if req.DatabaseUser != "" {
+		params.DbUser = aws.String(req.DatabaseUser)
+	}
+	if req.SecretArn != "" {
+		params.SecretArn = aws.String(req.SecretArn)
+	}
+
+	output, err := rd.ListDatabases(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to list amazon redshift databases: %s", err),
+			Status:         2,
+		}
+	}
+
+	var found bool
+	for _, db := range output.Databases {
+		if aws.StringValue(db) == req.DatabaseName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("amazon redshift database %q not found", req.DatabaseName),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon redshift database check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartRedshiftStatementExecution submits SQL statements to Amazon Redshift Data API.
+func (ex *ExecutorPlugin) StartRedshiftStatementExecution(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	rd := redshiftdataapiservice.New(sess)
+
+	var statementID string
+	var output interface{}
+
+	if len(req.SQLBatch) > 0 {
+		params := &redshiftdataapiservice.BatchExecuteStatementInput{
+			Database:      aws.String(req.DatabaseName),
+			Sqls:          aws.StringSlice(req.SQLBatch),
+			StatementName: aws.String(workflowID),
+		}
+		if req.ClusterIdentifier != "" {
+			params.ClusterIdentifier = aws.String(req.ClusterIdentifier)
+		}
+		if req.WorkgroupName != "" {
+			params.WorkgroupName = aws.String(req.WorkgroupName)
+		}
+		if req.DatabaseUser != "" {
+			params.DbUser = aws.String(req.DatabaseUser)
+		}
+		if req.SecretArn != "" {
+			params.SecretArn = aws.String(req.SecretArn)
+		}
+		batchOutput, err := rd.BatchExecuteStatement(params)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to start amazon redshift batch statement: %s", err),
+				Status:         2,
+			}
+		}
+		statementID = aws.StringValue(batchOutput.Id)
+		output = batchOutput
+	} else {
+		params := &redshiftdataapiservice.ExecuteStatementInput{
+			Database:      aws.String(req.DatabaseName),
+			Sql:           aws.String(req.SQL),
+			StatementName: aws.String(workflowID),
+		}
+		if req.ClusterIdentifier != "" {
+			params.ClusterIdentifier = aws.String(req.ClusterIdentifier)
+		}
+		if req.WorkgroupName != "" {
+			params.WorkgroupName = aws.String(req.WorkgroupName)
+		}
+		if req.DatabaseUser != "" {
+			params.DbUser = aws.String(req.DatabaseUser)
+		}
+		if req.SecretArn != "" {
+			params.SecretArn = aws.String(req.SecretArn)
+		}
+		stmtOutput, err := rd.ExecuteStatement(params)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to start amazon redshift statement: %s", err),
+				Status:         2,
+			}
+		}
+		statementID = aws.StringValue(stmtOutput.Id)
+		output = stmtOutput
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon redshift statement start response: %s", err),
+			Status:         2,
+		}
+	}
+
+	if statementID == "" {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("amazon redshift statement start response has no statement id"),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("started amazon redshift statement",
+		zap.String("plugin_name", app.Name),
+		zap.String("statement_id", statementID),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: statementID,
+	})
+
+	return &PluginResponse{
+		Message:       string(b),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 10 * time.Second,
+		},
+		Status: 3,
+		Outputs: map[string]string{
+			"statement_id": statementID,
+		},
+	}
+}
+
+// CheckRedshiftStatementExecution checks the status of Amazon Redshift Data API statement.
+func (ex *ExecutorPlugin) CheckRedshiftStatementExecution(req *PluginRequest, statementID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	rd := redshiftdataapiservice.New(sess)
+
+	params := &redshiftdataapiservice.DescribeStatementInput{
+		Id: aws.String(statementID),
+	}
+
+	output, err := rd.DescribeStatement(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon redshift statement: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon redshift statement execution response: %s", err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("checking amazon redshift statement",
+		zap.String("plugin_name", app.Name),
+		zap.String("statement_id", statementID),
+		zap.String("statement_status", aws.StringValue(output.Status)),
+	)
+
+	outputs := map[string]string{
+		"statement_id":  statementID,
+		"rows_affected": strconv.FormatInt(countRedshiftRowsAffected(output), 10),
+	}
+
+	// SUBMITTED | PICKED | STARTED | FINISHED | ABORTED | FAILED
+
+	switch aws.StringValue(output.Status) {
+	case redshiftdataapiservice.StatusStringFinished:
+		return &PluginResponse{
+			Message: string(b),
+			Status:  1,
+			Outputs: outputs,
+		}
+	case redshiftdataapiservice.StatusStringAborted, redshiftdataapiservice.StatusStringFailed:
+		msg := string(b)
+		if output.Error != nil {
+			msg = aws.StringValue(output.Error)
+		}
+		outputs["error"] = aws.StringValue(output.Error)
+		return &PluginResponse{
+			Message: msg,
+			Status:  4,
+			Outputs: outputs,
+		}
+	default:
+		// Covers Submitted, Picked and Started
+		return &PluginResponse{
+			Message:       string(b),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 10 * time.Second,
+			},
+			Status:  3,
+			Outputs: outputs,
+		}
+	}
+}
+
+// countRedshiftRowsAffected returns the number of rows affected by a statement.
+// For batch statements, it returns the sum across all sub-statements.
+func countRedshiftRowsAffected(output *redshiftdataapiservice.DescribeStatementOutput) int64 {
+	if len(output.SubStatements) == 0 {
+		if n := aws.Int64Value(output.ResultRows); n > 0 {
+			return n
+		}
+		return 0
+	}
+	var total int64
+	for _, sub := range output.SubStatements {
+		if n := aws.Int64Value(sub.ResultRows); n > 0 {
+			total += n
+		}
+	}
+	return total
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/redshiftdataapiservice"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCountRedshiftRowsAffected(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		output *redshiftdataapiservice.DescribeStatementOutput
+		want   int64
+	}{
+		{
+			name: "test single statement",
+			output: &redshiftdataapiservice.DescribeStatementOutput{
+				ResultRows: aws.Int64(42),
+			},
+			want: 42,
+		},
+		{
+			name: "test single statement without rows count",
+			output: &redshiftdataapiservice.DescribeStatementOutput{
+				ResultRows: aws.Int64(-1),
+			},
+			want: 0,
+		},
+		{
+			name:   "test single statement not finished",
+			output: &redshiftdataapiservice.DescribeStatementOutput{},
+			want:   0,
+		},
+		{
+			name: "test batch statement",
+			output: &redshiftdataapiservice.DescribeStatementOutput{
+				ResultRows: aws.Int64(-1),
+				SubStatements: []*redshiftdataapiservice.SubStatementData{
+					{ResultRows: aws.Int64(10)},
+					{ResultRows: aws.Int64(-1)},
+					{ResultRows: aws.Int64(5)},
+					{},
+				},
+			},
+			want: 15,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := countRedshiftRowsAffected(tc.output)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,645 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// s3MultipartCopyThreshold is the maximum size of an object copied with
+// a single CopyObject call.
+const s3MultipartCopyThreshold = 5 * 1024 * 1024 * 1024
+
+// s3MultipartCopyPartSize is the size of a part of multipart copy.
+const s3MultipartCopyPartSize = 512 * 1024 * 1024
+
+// s3MaxReportedFailures is the maximum number of failures included in
+// the response message.
+const s3MaxReportedFailures = 10
+
+// s3CopyBatchSize, s3DeleteBatchSize and s3TagBatchSize are the maximum
+// numbers of the objects under the prefix processed per request.
+const (
+	s3CopyBatchSize   = 50
+	s3DeleteBatchSize = 1000
+	s3TagBatchSize    = 200
+)
+
+// s3BatchRequeueDuration is the delay before processing the next batch of
+// the objects under the prefix.
+const s3BatchRequeueDuration = 5 * time.Second
+
+// CheckIfS3BucketExists checks whether a particular Amazon S3 bucket exists.
+func (ex *ExecutorPlugin) CheckIfS3BucketExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := s3.New(sess)
+
+	params := &s3.HeadBucketInput{
+		Bucket:              aws.String(req.BucketName),
+		ExpectedBucketOwner: aws.String(req.AccountID),
+	}
+
+	output, err := cli.HeadBucket(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon s3 bucket: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon s3 bucket check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// WaitForS3Objects checks whether the objects matching the key or the prefix
+// of the request exist in Amazon S3 bucket. The node remains running until
+// the objects appear or the wait timeout expires.
+func (ex *ExecutorPlugin) WaitForS3Objects(req *PluginRequest, workflowID string) *PluginResponse {
+	wf, exists := ex.getWorkflow(req, workflowID)
+	if !exists {
+		wf = &PluginWorkflow{
+			ID:        req.ResourceArn,
+			StartedAt: time.Now().UTC(),
+		}
+		ex.setWorkflow(req, workflowID, wf)
+		ex.Logger.Info("started waiting for amazon s3 objects",
+			zap.String("plugin_name", app.Name),
+			zap.String("resource_arn", req.ResourceArn),
+		)
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := s3.New(sess)
+
+	var modifiedAfter time.Time
+	if req.ModifiedAfter != "" {
+		// The timestamp format is checked during request validation.
+		modifiedAfter, _ = time.Parse(time.RFC3339, req.ModifiedAfter)
+	}
+
+	var keys []string
+	if req.ObjectKey != "" {
+		output, err := cli.HeadObject(&s3.HeadObjectInput{
+			Bucket:              aws.String(req.BucketName),
+			Key:                 aws.String(req.ObjectKey),
+			ExpectedBucketOwner: aws.String(req.AccountID),
+		})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "NotFound" {
+				return &PluginResponse{
+					ExecutionError: fmt.Errorf("failed to describe amazon s3 object: %s", err),
+					Status:         2,
+				}
+			}
+		} else if matchS3Object(aws.Int64Value(output.ContentLength), aws.TimeValue(output.LastModified), req.MinObjectSize, modifiedAfter) {
+			keys = append(keys, req.ObjectKey)
+		}
+	} else {
+		params := &s3.ListObjectsV2Input{
+			Bucket:              aws.String(req.BucketName),
+			Prefix:              aws.String(req.ObjectPrefix),
+			ExpectedBucketOwner: aws.String(req.AccountID),
+		}
+		err := cli.ListObjectsV2Pages(params, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, obj := range page.Contents {
+				if matchS3Object(aws.Int64Value(obj.Size), aws.TimeValue(obj.LastModified), req.MinObjectSize, modifiedAfter) {
+					keys = append(keys, aws.StringValue(obj.Key))
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to list amazon s3 objects: %s", err),
+				Status:         2,
+			}
+		}
+	}
+
+	minCount := req.MinObjectCount
+	if minCount < 1 {
+		minCount = 1
+	}
+
+	if int64(len(keys)) >= minCount {
+		b, err := json.Marshal(keys)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to pack amazon s3 object keys: %s", err),
+				Status:         2,
+			}
+		}
+
+		ex.Logger.Info("found amazon s3 objects",
+			zap.String("plugin_name", app.Name),
+			zap.String("resource_arn", req.ResourceArn),
+			zap.Int("matched_count", len(keys)),
+		)
+
+		return &PluginResponse{
+			Message: fmt.Sprintf("found %d amazon s3 objects in %s", len(keys), req.ResourceArn),
+			Status:  1,
+			Outputs: map[string]string{
+				"matched_keys":  string(b),
+				"matched_count": strconv.Itoa(len(keys)),
+			},
+		}
+	}
+
+	if req.WaitTimeout > 0 && time.Since(wf.StartedAt) > time.Duration(req.WaitTimeout)*time.Second {
+		return &PluginResponse{
+			ExecutionError:  fmt.Errorf("timed out after %ds waiting for amazon s3 objects in %s: found %d of %d", req.WaitTimeout, req.ResourceArn, len(keys), minCount),
+			Status:          2,
+			FailureCategory: FailureTimeout,
+		}
+	}
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("waiting for amazon s3 objects in %s: found %d of %d", req.ResourceArn, len(keys), minCount),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 30 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// matchS3Object checks whether the object satisfies the minimum size and
+// the last modification time conditions.
+func matchS3Object(size int64, lastModified time.Time, minSize int64, modifiedAfter time.Time) bool {
+	if size < minSize {
+		return false
+	}
+	if !modifiedAfter.IsZero() && !lastModified.After(modifiedAfter) {
+		return false
+	}
+	return true
+}
+
+// CopyS3Objects copies the object or the objects under the prefix of the
+// request to the destination bucket. The objects larger than 5 GiB are
+// copied with multipart upload. The objects under the prefix are copied in
+// batches, one batch per request, and the node remains running until all
+// the batches are copied.
+func (ex *ExecutorPlugin) CopyS3Objects(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := s3.New(sess)
+
+	dstBucket := req.DestBucketName
+	if dstBucket == "" {
+		dstBucket = req.BucketName
+	}
+
+	copyObject := func(key string, size int64) error {
+		var dstKey string
+		if req.ObjectKey != "" {
+			dstKey = req.DestObjectKey
+			if dstKey == "" {
+				dstKey = key
+			}
+		} else {
+			dstKey = req.DestObjectPrefix + strings.TrimPrefix(key, req.ObjectPrefix)
+		}
+
+		if size > s3MultipartCopyThreshold {
+			return copyS3ObjectMultipart(cli, req.BucketName, key, dstBucket, dstKey, size)
+		}
+		_, err := cli.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(dstBucket),
+			Key:        aws.String(dstKey),
+			CopySource: aws.String(buildS3CopySource(req.BucketName, key)),
+		})
+		return err
+	}
+
+	if req.ObjectKey != "" {
+		size, err := getS3ObjectSize(cli, req)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: err,
+				Status:         2,
+			}
+		}
+		var failures []string
+		if err := copyObject(req.ObjectKey, size); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", req.ObjectKey, err))
+		}
+		return ex.completeS3Batch(req, "copied", 1-len(failures), failures)
+	}
+
+	return ex.processS3ObjectBatch(cli, req, workflowID, "copied", s3CopyBatchSize, func(objects []*s3.Object) (int, []string, error) {
+		var processed int
+		var failures []string
+		for _, obj := range objects {
+			key := aws.StringValue(obj.Key)
+			if err := copyObject(key, aws.Int64Value(obj.Size)); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", key, err))
+				continue
+			}
+			processed++
+		}
+		return processed, failures, nil
+	})
+}
+
+// DeleteS3Objects deletes the object or the objects under the prefix of the
+// request. The objects under the prefix are deleted in batches, one batch per
+// request, and the node remains running until all the batches are deleted.
+func (ex *ExecutorPlugin) DeleteS3Objects(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := s3.New(sess)
+
+	if req.ObjectKey != "" {
+		var failures []string
+		if _, err := cli.DeleteObject(&s3.DeleteObjectInput{
+			Bucket:              aws.String(req.BucketName),
+			Key:                 aws.String(req.ObjectKey),
+			ExpectedBucketOwner: aws.String(req.AccountID),
+		}); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", req.ObjectKey, err))
+		}
+		return ex.completeS3Batch(req, "deleted", 1-len(failures), failures)
+	}
+
+	return ex.processS3ObjectBatch(cli, req, workflowID, "deleted", s3DeleteBatchSize, func(objects []*s3.Object) (int, []string, error) {
+		if len(objects) == 0 {
+			return 0, nil, nil
+		}
+		identifiers := make([]*s3.ObjectIdentifier, 0, len(objects))
+		for _, obj := range objects {
+			identifiers = append(identifiers, &s3.ObjectIdentifier{Key: obj.Key})
+		}
+		output, err := cli.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(req.BucketName),
+			Delete: &s3.Delete{
+				Objects: identifiers,
+				Quiet:   aws.Bool(true),
+			},
+			ExpectedBucketOwner: aws.String(req.AccountID),
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to delete amazon s3 objects: %s", err)
+		}
+		var failures []string
+		for _, e := range output.Errors {
+			failures = append(failures, fmt.Sprintf("%s: %s (%s)",
+				aws.StringValue(e.Key), aws.StringValue(e.Code), aws.StringValue(e.Message)))
+		}
+		return len(identifiers) - len(output.Errors), failures, nil
+	})
+}
+
+// TagS3Objects replaces the tag set of the object or the objects under the
+// prefix of the request with the tags of the request. The objects under the
+// prefix are tagged in batches, one batch per request, and the node remains
+// running until all the batches are tagged.
+func (ex *ExecutorPlugin) TagS3Objects(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := s3.New(sess)
+
+	keys := make([]string, 0, len(req.ObjectTags))
+	for k := range req.ObjectTags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	tagging := &s3.Tagging{}
+	for _, k := range keys {
+		tagging.TagSet = append(tagging.TagSet, &s3.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(req.ObjectTags[k]),
+		})
+	}
+
+	tagObject := func(key string) error {
+		_, err := cli.PutObjectTagging(&s3.PutObjectTaggingInput{
+			Bucket:              aws.String(req.BucketName),
+			Key:                 aws.String(key),
+			Tagging:             tagging,
+			ExpectedBucketOwner: aws.String(req.AccountID),
+		})
+		return err
+	}
+
+	if req.ObjectKey != "" {
+		if _, err := getS3ObjectSize(cli, req); err != nil {
+			return &PluginResponse{
+				ExecutionError: err,
+				Status:         2,
+			}
+		}
+		var failures []string
+		if err := tagObject(req.ObjectKey); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", req.ObjectKey, err))
+		}
+		return ex.completeS3Batch(req, "tagged", 1-len(failures), failures)
+	}
+
+	return ex.processS3ObjectBatch(cli, req, workflowID, "tagged", s3TagBatchSize, func(objects []*s3.Object) (int, []string, error) {
+		var processed int
+		var failures []string
+		for _, obj := range objects {
+			key := aws.StringValue(obj.Key)
+			if err := tagObject(key); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", key, err))
+				continue
+			}
+			processed++
+		}
+		return processed, failures, nil
+	})
+}
+
+// s3BatchProgress holds the progress of an operation over the objects under
+// a prefix processed in batches across requests.
+type s3BatchProgress struct {
+	continuationToken string
+	processed         int
+	failures          []string
+}
+
+// processS3ObjectBatch lists the next batch of the objects under the prefix
+// of the request and passes it to the function. The continuation token of
+// the listing is kept in the workflow and the node remains running until
+// the last batch is processed.
+func (ex *ExecutorPlugin) processS3ObjectBatch(cli *s3.S3, req *PluginRequest, workflowID, verb string, batchSize int64, fn func(objects []*s3.Object) (int, []string, error)) *PluginResponse {
+	wf, exists := ex.getWorkflow(req, workflowID)
+	if !exists || wf.s3Batch == nil {
+		wf = &PluginWorkflow{
+			ID:        req.ResourceArn,
+			StartedAt: time.Now().UTC(),
+			s3Batch:   &s3BatchProgress{},
+		}
+		ex.setWorkflow(req, workflowID, wf)
+	}
+	progress := wf.s3Batch
+
+	params := &s3.ListObjectsV2Input{
+		Bucket:              aws.String(req.BucketName),
+		Prefix:              aws.String(req.ObjectPrefix),
+		MaxKeys:             aws.Int64(batchSize),
+		ExpectedBucketOwner: aws.String(req.AccountID),
+	}
+	if progress.continuationToken != "" {
+		params.ContinuationToken = aws.String(progress.continuationToken)
+	}
+	output, err := cli.ListObjectsV2(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to list amazon s3 objects after %d %s: %s", progress.processed, verb, err),
+			Status:         2,
+		}
+	}
+
+	processed, failures, err := fn(output.Contents)
+	progress.processed += processed
+	progress.failures = append(progress.failures, failures...)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("%s after %d %s", err, progress.processed, verb),
+			Status:         2,
+		}
+	}
+
+	if aws.BoolValue(output.IsTruncated) {
+		progress.continuationToken = aws.StringValue(output.NextContinuationToken)
+		return &PluginResponse{
+			Message: fmt.Sprintf("%s %d amazon s3 objects in %s, failed %d", verb, progress.processed, req.ResourceArn, len(progress.failures)),
+			Outputs: map[string]string{
+				"processed_count": strconv.Itoa(progress.processed),
+				"failed_count":    strconv.Itoa(len(progress.failures)),
+			},
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: s3BatchRequeueDuration,
+			},
+			Status: 3,
+		}
+	}
+
+	return ex.completeS3Batch(req, verb, progress.processed, progress.failures)
+}
+
+// completeS3Batch logs the completion of an operation over many objects and
+// returns its response.
+func (ex *ExecutorPlugin) completeS3Batch(req *PluginRequest, verb string, processed int, failures []string) *PluginResponse {
+	ex.Logger.Info(verb+" amazon s3 objects",
+		zap.String("plugin_name", app.Name),
+		zap.String("resource_arn", req.ResourceArn),
+		zap.Int("processed_count", processed),
+		zap.Int("failed_count", len(failures)),
+	)
+	return buildS3BatchResponse(verb, processed, failures)
+}
+
+// getS3ObjectSize returns the size of the object of the request.
+func getS3ObjectSize(cli *s3.S3, req *PluginRequest) (int64, error) {
+	output, err := cli.HeadObject(&s3.HeadObjectInput{
+		Bucket:              aws.String(req.BucketName),
+		Key:                 aws.String(req.ObjectKey),
+		ExpectedBucketOwner: aws.String(req.AccountID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe amazon s3 object: %s", err)
+	}
+	return aws.Int64Value(output.ContentLength), nil
+}
+
+// copyS3ObjectMultipart copies the object in parts. The content type, the
+// metadata, the tags and the server-side encryption of the source object are
+// carried over to the copy. The upload is aborted when any of the parts
+// fails.
+func copyS3ObjectMultipart(cli *s3.S3, srcBucket, srcKey, dstBucket, dstKey string, size int64) error {
+	head, err := cli.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe source object: %s", err)
+	}
+	tagging, err := cli.GetObjectTagging(&s3.GetObjectTaggingInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get source object tags: %s", err)
+	}
+
+	upload, err := cli.CreateMultipartUpload(buildS3MultipartUploadInput(dstBucket, dstKey, head, tagging.TagSet))
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %s", err)
+	}
+
+	abort := func(err error) error {
+		if _, abortErr := cli.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(dstBucket),
+			Key:      aws.String(dstKey),
+			UploadId: upload.UploadId,
+		}); abortErr != nil {
+			return fmt.Errorf("%s, failed to abort multipart upload %s: %s", err, aws.StringValue(upload.UploadId), abortErr)
+		}
+		return err
+	}
+
+	var parts []*s3.CompletedPart
+	for i, start := int64(1), int64(0); start < size; i, start = i+1, start+s3MultipartCopyPartSize {
+		end := start + s3MultipartCopyPartSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		output, err := cli.UploadPartCopy(&s3.UploadPartCopyInput{
+			Bucket:          aws.String(dstBucket),
+			Key:             aws.String(dstKey),
+			CopySource:      aws.String(buildS3CopySource(srcBucket, srcKey)),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+			PartNumber:      aws.Int64(i),
+			UploadId:        upload.UploadId,
+		})
+		if err != nil {
+			return abort(fmt.Errorf("failed to copy part %d: %s", i, err))
+		}
+		parts = append(parts, &s3.CompletedPart{
+			ETag:       output.CopyPartResult.ETag,
+			PartNumber: aws.Int64(i),
+		})
+	}
+
+	if _, err := cli.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(dstBucket),
+		Key:             aws.String(dstKey),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		return abort(fmt.Errorf("failed to complete multipart upload: %s", err))
+	}
+	return nil
+}
+
+// buildS3MultipartUploadInput returns the input of the multipart upload
+// of the copy with the properties of the source object.
+func buildS3MultipartUploadInput(dstBucket, dstKey string, head *s3.HeadObjectOutput, tags []*s3.Tag) *s3.CreateMultipartUploadInput {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(dstBucket),
+		Key:                aws.String(dstKey),
+		CacheControl:       head.CacheControl,
+		ContentDisposition: head.ContentDisposition,
+		ContentEncoding:    head.ContentEncoding,
+		ContentLanguage:    head.ContentLanguage,
+		ContentType:        head.ContentType,
+		Metadata:           head.Metadata,
+	}
+	if head.ServerSideEncryption != nil {
+		input.ServerSideEncryption = head.ServerSideEncryption
+		input.SSEKMSKeyId = head.SSEKMSKeyId
+		input.BucketKeyEnabled = head.BucketKeyEnabled
+	}
+	if len(tags) > 0 {
+		values := url.Values{}
+		for _, tag := range tags {
+			values.Add(aws.StringValue(tag.Key), aws.StringValue(tag.Value))
+		}
+		input.Tagging = aws.String(values.Encode())
+	}
+	return input
+}
+
+// buildS3CopySource returns URL-encoded copy source of the object.
+func buildS3CopySource(bucket, key string) string {
+	return url.PathEscape(bucket + "/" + key)
+}
+
+// buildS3BatchResponse returns the response of an operation over many
+// objects with the counts of processed and failed objects.
+func buildS3BatchResponse(verb string, processed int, failures []string) *PluginResponse {
+	outputs := map[string]string{
+		"processed_count": strconv.Itoa(processed),
+		"failed_count":    strconv.Itoa(len(failures)),
+	}
+
+	if len(failures) > 0 {
+		reported := failures
+		if len(reported) > s3MaxReportedFailures {
+			reported = reported[:s3MaxReportedFailures]
+		}
+		return &PluginResponse{
+			Message: fmt.Sprintf("%s %d amazon s3 objects, failed %d: %s",
+				verb, processed, len(failures), strings.Join(reported, "; ")),
+			Status:  2,
+			Outputs: outputs,
+		}
+	}
+
+	return &PluginResponse{
+		Message: fmt.Sprintf("%s %d amazon s3 objects", verb, processed),
+		Status:  1,
+		Outputs: outputs,
+	}
+}
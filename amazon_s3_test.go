@@ -0,0 +1,217 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchS3Object(t *testing.T) {
+	cutoff := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	var testcases = []struct {
+		name          string
+		size          int64
+		lastModified  time.Time
+		minSize       int64
+		modifiedAfter time.Time
+		want          bool
+	}{
+		{
+			name:         "test object without conditions",
+			size:         0,
+			lastModified: cutoff,
+			want:         true,
+		},
+		{
+			name:         "test object smaller than minimum size",
+			size:         512,
+			lastModified: cutoff,
+			minSize:      1024,
+			want:         false,
+		},
+		{
+			name:          "test object modified after cutoff",
+			size:          2048,
+			lastModified:  cutoff.Add(time.Minute),
+			minSize:       1024,
+			modifiedAfter: cutoff,
+			want:          true,
+		},
+		{
+			name:          "test object modified at cutoff",
+			size:          2048,
+			lastModified:  cutoff,
+			modifiedAfter: cutoff,
+			want:          false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := matchS3Object(tc.size, tc.lastModified, tc.minSize, tc.modifiedAfter)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestValidateS3Copy(t *testing.T) {
+	var testcases = []struct {
+		name      string
+		req       *PluginRequest
+		shouldErr bool
+		err       error
+	}{
+		{
+			name: "test copy prefix to another prefix",
+			req: &PluginRequest{
+				BucketName:       "foo",
+				ObjectPrefix:     "data/",
+				DestObjectPrefix: "archive/data/",
+			},
+		},
+		{
+			name: "test copy prefix to same prefix in another bucket",
+			req: &PluginRequest{
+				BucketName:       "foo",
+				ObjectPrefix:     "data/",
+				DestBucketName:   "bar",
+				DestObjectPrefix: "data/",
+			},
+		},
+		{
+			name: "test copy prefix to same prefix",
+			req: &PluginRequest{
+				BucketName:       "foo",
+				ObjectPrefix:     "data/",
+				DestObjectPrefix: "data/",
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("destination prefix is within source prefix"),
+		},
+		{
+			name: "test copy prefix to nested prefix",
+			req: &PluginRequest{
+				BucketName:       "foo",
+				ObjectPrefix:     "data/",
+				DestObjectPrefix: "data/archive/",
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("destination prefix is within source prefix"),
+		},
+		{
+			name: "test copy prefix to nested prefix in same bucket by name",
+			req: &PluginRequest{
+				BucketName:       "foo",
+				ObjectPrefix:     "data",
+				DestBucketName:   "foo",
+				DestObjectPrefix: "data-archive/",
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("destination prefix is within source prefix"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.Action = "copy"
+			err := tc.req.validateS3()
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatalf("test name: %s, expected error, but got success", tc.name)
+				}
+				if diff := cmp.Diff(tc.err.Error(), err.Error()); diff != "" {
+					t.Fatalf("test name: %s, unexpected error (-want +got):\n%s", tc.name, diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, err)
+			}
+		})
+	}
+}
+
+func TestBuildS3MultipartUploadInput(t *testing.T) {
+	var testcases = []struct {
+		name string
+		head *s3.HeadObjectOutput
+		tags []*s3.Tag
+		want *s3.CreateMultipartUploadInput
+	}{
+		{
+			name: "test copy object without properties",
+			head: &s3.HeadObjectOutput{},
+			want: &s3.CreateMultipartUploadInput{
+				Bucket: aws.String("bar"),
+				Key:    aws.String("archive/data.csv"),
+			},
+		},
+		{
+			name: "test copy object with content type, metadata and tags",
+			head: &s3.HeadObjectOutput{
+				ContentType: aws.String("text/csv"),
+				Metadata: map[string]*string{
+					"Source": aws.String("ingest"),
+				},
+			},
+			tags: []*s3.Tag{
+				{Key: aws.String("team"), Value: aws.String("data eng")},
+				{Key: aws.String("env"), Value: aws.String("prod")},
+			},
+			want: &s3.CreateMultipartUploadInput{
+				Bucket:      aws.String("bar"),
+				Key:         aws.String("archive/data.csv"),
+				ContentType: aws.String("text/csv"),
+				Metadata: map[string]*string{
+					"Source": aws.String("ingest"),
+				},
+				Tagging: aws.String("env=prod&team=data+eng"),
+			},
+		},
+		{
+			name: "test copy object encrypted with kms key",
+			head: &s3.HeadObjectOutput{
+				ServerSideEncryption: aws.String("aws:kms"),
+				SSEKMSKeyId:          aws.String("arn:aws:kms:us-east-1:100000000002:key/1a2b3c4d"),
+				BucketKeyEnabled:     aws.Bool(true),
+			},
+			want: &s3.CreateMultipartUploadInput{
+				Bucket:               aws.String("bar"),
+				Key:                  aws.String("archive/data.csv"),
+				ServerSideEncryption: aws.String("aws:kms"),
+				SSEKMSKeyId:          aws.String("arn:aws:kms:us-east-1:100000000002:key/1a2b3c4d"),
+				BucketKeyEnabled:     aws.Bool(true),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildS3MultipartUploadInput("bar", "archive/data.csv", tc.head, tc.tags)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
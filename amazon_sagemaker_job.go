@@ -0,0 +1,241 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sageMakerJobNameMaxLength is the maximum length of the name of SageMaker
+// training, processing and batch transform jobs.
+const sageMakerJobNameMaxLength = 63
+
+// sageMakerJobInput is the input creating SageMaker job.
+type sageMakerJobInput interface {
+	Validate() error
+}
+
+// sageMakerJobStatus is the status of SageMaker job.
+type sageMakerJobStatus struct {
+	Status          string
+	SecondaryStatus string
+	FailureReason   string
+	Outputs         map[string]string
+}
+
+// sageMakerJobKind describes the operations on the kind of SageMaker job,
+// i.e. training, processing and batch transform jobs.
+type sageMakerJobKind struct {
+	// name is the name of the kind in messages, e.g. training.
+	name string
+	// newInput decodes the job spec of the request into the input creating
+	// the job with the name.
+	newInput func(req *PluginRequest, jobName string) (sageMakerJobInput, error)
+	// create creates the job and returns the output of the call and the ARN
+	// of the job.
+	create func(sm *sagemaker.SageMaker, input sageMakerJobInput) (interface{}, string, error)
+	// describe returns the status of the job.
+	describe func(sm *sagemaker.SageMaker, jobName string) (*sageMakerJobStatus, error)
+}
+
+// sageMakerJobKinds maps the services to the kinds of SageMaker jobs.
+var sageMakerJobKinds = map[string]*sageMakerJobKind{
+	"amazon_sagemaker_training":   sageMakerTrainingJob,
+	"amazon_sagemaker_processing": sageMakerProcessingJob,
+	"amazon_sagemaker_transform":  sageMakerTransformJob,
+}
+
+var sageMakerJobNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// buildSageMakerJobName returns the unique name of SageMaker job. The name
+// of the job in the request is suffixed with the workflow ID and the start
+// time, because the names of SageMaker jobs cannot be reused, and the
+// retried or resubmitted workflows create new jobs.
+func buildSageMakerJobName(jobName, workflowID string, startedAt time.Time) string {
+	id := sageMakerJobNameInvalidChars.ReplaceAllString(workflowID, "")
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	suffix := "-" + strconv.FormatInt(startedAt.Unix(), 36)
+	if id != "" {
+		suffix = "-" + id + suffix
+	}
+	name := sageMakerJobNameInvalidChars.ReplaceAllString(jobName, "-")
+	if len(name) > sageMakerJobNameMaxLength-len(suffix) {
+		name = name[:sageMakerJobNameMaxLength-len(suffix)]
+	}
+	return strings.TrimRight(name, "-") + suffix
+}
+
+// ValidateSageMakerJob checks whether the SageMaker job specification is valid.
+func (ex *ExecutorPlugin) ValidateSageMakerJob(req *PluginRequest, kind *sageMakerJobKind, workflowID string) *PluginResponse {
+	params, err := kind.newInput(req, buildSageMakerJobName(req.JobName, workflowID, time.Now()))
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	if err := params.Validate(); err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("invalid amazon sagemaker %s job spec: %s", kind.name, err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Status: 1,
+	}
+}
+
+// StartSageMakerJobExecution creates SageMaker job.
+func (ex *ExecutorPlugin) StartSageMakerJobExecution(req *PluginRequest, kind *sageMakerJobKind, workflowID string) *PluginResponse {
+	jobName := buildSageMakerJobName(req.JobName, workflowID, time.Now())
+	params, err := kind.newInput(req, jobName)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	sm := sagemaker.New(sess)
+
+	output, jobArn, err := kind.create(sm, params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create amazon sagemaker %s job: %s", kind.name, err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon sagemaker %s job start response: %s", kind.name, err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info(fmt.Sprintf("started sagemaker %s job", kind.name),
+		zap.String("plugin_name", app.Name),
+		zap.String("job_name", jobName),
+		zap.String("job_arn", jobArn),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: jobName,
+	})
+
+	return &PluginResponse{
+		Message:       string(b),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 60 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// CheckSageMakerJobExecution checks the status of SageMaker job.
+func (ex *ExecutorPlugin) CheckSageMakerJobExecution(req *PluginRequest, kind *sageMakerJobKind, jobName string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	sm := sagemaker.New(sess)
+
+	status, err := kind.describe(sm, jobName)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon sagemaker %s job: %s", kind.name, err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info(fmt.Sprintf("checking sagemaker %s job", kind.name),
+		zap.String("plugin_name", app.Name),
+		zap.String("job_name", jobName),
+		zap.String("job_status", status.Status),
+		zap.String("job_secondary_status", status.SecondaryStatus),
+	)
+
+	return buildSageMakerJobResponse(kind.name, jobName, status)
+}
+
+// buildSageMakerJobResponse returns the response for the status of SageMaker
+// job. The statuses of training, processing and batch transform jobs are the
+// same.
+func buildSageMakerJobResponse(kind, jobName string, status *sageMakerJobStatus) *PluginResponse {
+	outputs := map[string]string{
+		"job_name": jobName,
+	}
+	for k, v := range status.Outputs {
+		outputs[k] = v
+	}
+
+	switch status.Status {
+	case sagemaker.TrainingJobStatusCompleted:
+		return &PluginResponse{
+			Message: fmt.Sprintf("amazon sagemaker %s job %s completed", kind, jobName),
+			Status:  1,
+			Outputs: outputs,
+		}
+	case sagemaker.TrainingJobStatusStopped, sagemaker.TrainingJobStatusFailed:
+		outputs["failure_reason"] = status.FailureReason
+		return &PluginResponse{
+			Message: fmt.Sprintf("amazon sagemaker %s job %s is %s: %s",
+				kind, jobName, status.Status, status.FailureReason),
+			Status:  4,
+			Outputs: outputs,
+		}
+	default:
+		// Covers InProgress and Stopping
+		msg := fmt.Sprintf("amazon sagemaker %s job %s is %s", kind, jobName, status.Status)
+		if status.SecondaryStatus != "" {
+			msg += fmt.Sprintf(" (%s)", status.SecondaryStatus)
+		}
+		return &PluginResponse{
+			Message:       msg,
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 60 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildSageMakerJobName(t *testing.T) {
+	startedAt := time.Unix(1697600000, 0)
+
+	var testcases = []struct {
+		name       string
+		jobName    string
+		workflowID string
+		want       string
+	}{
+		{
+			name:       "test job name with workflow id",
+			jobName:    "my-training-job",
+			workflowID: "27c01e7c-9d93-450f-a001-c64d649aac99",
+			want:       "my-training-job-27c01e7c-s2pgjk",
+		},
+		{
+			name:       "test job name with invalid characters",
+			jobName:    "my_training.job",
+			workflowID: "27c01e7c-9d93-450f-a001-c64d649aac99",
+			want:       "my-training-job-27c01e7c-s2pgjk",
+		},
+		{
+			name:       "test long job name",
+			jobName:    strings.Repeat("a", 70),
+			workflowID: "27c01e7c-9d93-450f-a001-c64d649aac99",
+			want:       strings.Repeat("a", 47) + "-27c01e7c-s2pgjk",
+		},
+		{
+			name:    "test job name without workflow id",
+			jobName: "my-training-job",
+			want:    "my-training-job-s2pgjk",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildSageMakerJobName(tc.jobName, tc.workflowID, startedAt)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+			if len(got) > sageMakerJobNameMaxLength {
+				t.Fatalf("test name: %s, job name %s exceeds %d characters", tc.name, got, sageMakerJobNameMaxLength)
+			}
+		})
+	}
+}
+
+func TestBuildSageMakerJobResponse(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		kind   string
+		status *sageMakerJobStatus
+		want   *PluginResponse
+	}{
+		{
+			name: "test completed training job",
+			kind: "training",
+			status: &sageMakerJobStatus{
+				Status: "Completed",
+				Outputs: map[string]string{
+					"job_arn":         "arn:aws:sagemaker:us-west-2:100000000002:training-job/foo",
+					"model_artifacts": "s3://bucket/model.tar.gz",
+				},
+			},
+			want: &PluginResponse{
+				Message: "amazon sagemaker training job foo completed",
+				Status:  1,
+				Outputs: map[string]string{
+					"job_name":        "foo",
+					"job_arn":         "arn:aws:sagemaker:us-west-2:100000000002:training-job/foo",
+					"model_artifacts": "s3://bucket/model.tar.gz",
+				},
+			},
+		},
+		{
+			name: "test failed processing job",
+			kind: "processing",
+			status: &sageMakerJobStatus{
+				Status:        "Failed",
+				FailureReason: "AlgorithmError: exit code 1",
+				Outputs: map[string]string{
+					"job_arn": "arn:aws:sagemaker:us-west-2:100000000002:processing-job/foo",
+				},
+			},
+			want: &PluginResponse{
+				Message: "amazon sagemaker processing job foo is Failed: AlgorithmError: exit code 1",
+				Status:  4,
+				Outputs: map[string]string{
+					"job_name":       "foo",
+					"job_arn":        "arn:aws:sagemaker:us-west-2:100000000002:processing-job/foo",
+					"failure_reason": "AlgorithmError: exit code 1",
+				},
+			},
+		},
+		{
+			name: "test running training job",
+			kind: "training",
+			status: &sageMakerJobStatus{
+				Status:          "InProgress",
+				SecondaryStatus: "Downloading",
+			},
+			want: &PluginResponse{
+				Message:       "amazon sagemaker training job foo is InProgress (Downloading)",
+				ShouldRequeue: true,
+				RequeueDuration: &metav1.Duration{
+					Duration: 60 * time.Second,
+				},
+				Status: 3,
+			},
+		},
+		{
+			name: "test stopping transform job",
+			kind: "transform",
+			status: &sageMakerJobStatus{
+				Status: "Stopping",
+			},
+			want: &PluginResponse{
+				Message:       "amazon sagemaker transform job foo is Stopping",
+				ShouldRequeue: true,
+				RequeueDuration: &metav1.Duration{
+					Duration: 60 * time.Second,
+				},
+				Status: 3,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildSageMakerJobResponse(tc.kind, "foo", tc.status)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestValidateSageMakerJob(t *testing.T) {
+	ex := &ExecutorPlugin{}
+
+	var testcases = []struct {
+		name      string
+		service   string
+		jobSpec   map[string]interface{}
+		shouldErr bool
+		err       error
+	}{
+		{
+			name:    "test valid transform job spec",
+			service: "amazon_sagemaker_transform",
+			jobSpec: map[string]interface{}{
+				"ModelName": "my-model",
+				"TransformInput": map[string]interface{}{
+					"DataSource": map[string]interface{}{
+						"S3DataSource": map[string]interface{}{
+							"S3DataType": "S3Prefix",
+							"S3Uri":      "s3://bucket/input/",
+						},
+					},
+				},
+				"TransformOutput": map[string]interface{}{
+					"S3OutputPath": "s3://bucket/output/",
+				},
+				"TransformResources": map[string]interface{}{
+					"InstanceCount": 1,
+					"InstanceType":  "ml.m5.large",
+				},
+			},
+		},
+		{
+			name:    "test training job spec without role",
+			service: "amazon_sagemaker_training",
+			jobSpec: map[string]interface{}{
+				"AlgorithmSpecification": map[string]interface{}{
+					"TrainingInputMode": "File",
+				},
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("invalid amazon sagemaker training job spec: InvalidParameter: 4 validation error(s) found.\n- missing required field, CreateTrainingJobInput.OutputDataConfig.\n- missing required field, CreateTrainingJobInput.ResourceConfig.\n- missing required field, CreateTrainingJobInput.RoleArn.\n- missing required field, CreateTrainingJobInput.StoppingCondition.\n"),
+		},
+		{
+			name:      "test processing job spec without fields",
+			service:   "amazon_sagemaker_processing",
+			jobSpec:   map[string]interface{}{"Environment": map[string]interface{}{}},
+			shouldErr: true,
+			err:       fmt.Errorf("invalid amazon sagemaker processing job spec: InvalidParameter: 3 validation error(s) found.\n- missing required field, CreateProcessingJobInput.AppSpecification.\n- missing required field, CreateProcessingJobInput.ProcessingResources.\n- missing required field, CreateProcessingJobInput.RoleArn.\n"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &PluginRequest{
+				ServiceName: tc.service,
+				JobName:     "foo",
+				JobSpec:     tc.jobSpec,
+			}
+			resp := ex.ValidateSageMakerJob(req, sageMakerJobKinds[tc.service], "27c01e7c-9d93-450f-a001-c64d649aac99")
+			if tc.shouldErr {
+				if resp.ExecutionError == nil {
+					t.Fatalf("test name: %s, expected error, but got success", tc.name)
+				}
+				if diff := cmp.Diff(tc.err.Error(), resp.ExecutionError.Error()); diff != "" {
+					t.Fatalf("test name: %s, unexpected error (-want +got):\n%s", tc.name, diff)
+				}
+				return
+			}
+			if resp.ExecutionError != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, resp.ExecutionError)
+			}
+		})
+	}
+}
@@ -17,10 +17,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sagemaker"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,9 +28,7 @@ import (
 
 // CheckIfSageMakerPipelineExists checks whether a particular SageMaker Pipelines instance exists.
 func (ex *ExecutorPlugin) CheckIfSageMakerPipelineExists(req *PluginRequest) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -68,9 +66,7 @@ func (ex *ExecutorPlugin) CheckIfSageMakerPipelineExists(req *PluginRequest) *Pl
 
 // StartSageMakerPipelineExecution starts SageMaker Pipelines instance.
 func (ex *ExecutorPlugin) StartSageMakerPipelineExecution(req *PluginRequest, workflowID string) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -112,9 +108,9 @@ func (ex *ExecutorPlugin) StartSageMakerPipelineExecution(req *PluginRequest, wo
 		zap.String("execution_arn", executionArn),
 	)
 
-	ex.Workflows[workflowID] = &PluginWorkflow{
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
 		ID: executionArn,
-	}
+	})
 
 	return &PluginResponse{
 		Message:       string(b),
@@ -128,9 +124,7 @@ func (ex *ExecutorPlugin) StartSageMakerPipelineExecution(req *PluginRequest, wo
 
 // CheckSageMakerPipelineExecution checks the status of SageMaker Pipelines execution.
 func (ex *ExecutorPlugin) CheckSageMakerPipelineExecution(req *PluginRequest, executionID string) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -152,40 +146,202 @@ func (ex *ExecutorPlugin) CheckSageMakerPipelineExecution(req *PluginRequest, ex
 		}
 	}
 
-	b, err := json.Marshal(output)
-	if err != nil {
-		return &PluginResponse{
-			ExecutionError: fmt.Errorf("failed to pack amazon sagemaker pipeline execution response: %s", err),
-			Status:         2,
-		}
-	}
+	status := aws.StringValue(output.PipelineExecutionStatus)
 
 	ex.Logger.Info("checking sagemaker pipeline instance",
 		zap.String("plugin_name", app.Name),
 		zap.String("execution_arn", executionID),
-		zap.String("execution_status", *output.PipelineExecutionStatus),
+		zap.String("execution_status", status),
 	)
 
-	switch *output.PipelineExecutionStatus {
+	// The progress is optional. The status of the execution is reported
+	// without it when the steps cannot be listed, e.g. due to missing IAM
+	// permissions.
+	msg := fmt.Sprintf("amazon sagemaker pipeline execution is %s", status)
+	progress, err := ex.getSageMakerPipelineProgress(sm, executionID)
+	if err != nil {
+		ex.Logger.Warn("failed to get sagemaker pipeline progress",
+			zap.String("plugin_name", app.Name),
+			zap.String("execution_arn", executionID),
+			zap.Error(err),
+		)
+		progress = &sageMakerPipelineProgress{}
+	} else {
+		msg += ": " + progress.String()
+	}
+
+	switch status {
 	case "Succeeded":
 		return &PluginResponse{
-			Message: string(b),
-			Status:  1,
+			Message:  msg,
+			Status:   1,
+			Progress: newProgress(int64(progress.Completed), int64(progress.Total)),
 		}
 	case "Stopped", "Failed":
+		failureReason := progress.FailureReason
+		if failureReason == "" {
+			failureReason = aws.StringValue(output.FailureReason)
+		}
+		if progress.FailedStep != "" {
+			msg += fmt.Sprintf("; step %s failed", progress.FailedStep)
+		}
+		if failureReason != "" {
+			msg += ": " + failureReason
+		}
 		return &PluginResponse{
-			Message: string(b),
-			Status:  2,
+			Message: msg,
+			Status:  4,
+			Outputs: map[string]string{
+				"failed_step":    progress.FailedStep,
+				"failure_reason": failureReason,
+			},
+			Progress: newProgress(int64(progress.Completed), int64(progress.Total)),
 		}
 	default:
 		// Covers Stopping and Executing
 		return &PluginResponse{
-			Message:       string(b),
+			Message:       msg,
 			ShouldRequeue: true,
 			RequeueDuration: &metav1.Duration{
 				Duration: 60 * time.Second,
 			},
-			Status: 3,
+			Status:   3,
+			Progress: newProgress(int64(progress.Completed), int64(progress.Total)),
+		}
+	}
+}
+
+// sageMakerPipelineProgress describes the progress of SageMaker Pipelines
+// execution.
+type sageMakerPipelineProgress struct {
+	Completed     int
+	Total         int
+	Executing     []string
+	FailedStep    string
+	FailureReason string
+}
+
+// String returns the number of completed steps and the steps being executed.
+func (p *sageMakerPipelineProgress) String() string {
+	s := fmt.Sprintf("%d/%d steps completed", p.Completed, p.Total)
+	if len(p.Executing) > 0 {
+		s += ", executing " + strings.Join(p.Executing, ", ")
+	}
+	return s
+}
+
+// sageMakerPipelineStepDefinition is the step of SageMaker Pipelines
+// definition. Condition steps have nested steps in their arguments.
+type sageMakerPipelineStepDefinition struct {
+	Name      string `json:"Name"`
+	Type      string `json:"Type"`
+	Arguments struct {
+		IfSteps   []*sageMakerPipelineStepDefinition `json:"IfSteps"`
+		ElseSteps []*sageMakerPipelineStepDefinition `json:"ElseSteps"`
+	} `json:"Arguments"`
+}
+
+// getSageMakerPipelineProgress returns the progress of SageMaker Pipelines
+// execution based on its steps and its pipeline definition. The progress is
+// based on the executed steps only when the definition is not available.
+func (ex *ExecutorPlugin) getSageMakerPipelineProgress(sm *sagemaker.SageMaker, executionID string) (*sageMakerPipelineProgress, error) {
+	var steps []*sagemaker.PipelineExecutionStep
+	if err := sm.ListPipelineExecutionStepsPages(&sagemaker.ListPipelineExecutionStepsInput{
+		PipelineExecutionArn: aws.String(executionID),
+	}, func(page *sagemaker.ListPipelineExecutionStepsOutput, lastPage bool) bool {
+		steps = append(steps, page.PipelineExecutionSteps...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list amazon sagemaker pipeline execution steps: %s", err)
+	}
+
+	var definition []*sageMakerPipelineStepDefinition
+	output, err := sm.DescribePipelineDefinitionForExecution(&sagemaker.DescribePipelineDefinitionForExecutionInput{
+		PipelineExecutionArn: aws.String(executionID),
+	})
+	if err != nil {
+		ex.Logger.Warn("failed to describe amazon sagemaker pipeline definition",
+			zap.String("plugin_name", app.Name),
+			zap.String("execution_arn", executionID),
+			zap.Error(err),
+		)
+	} else {
+		pipelineDefinition := struct {
+			Steps []*sageMakerPipelineStepDefinition `json:"Steps"`
+		}{}
+		if err := json.Unmarshal([]byte(aws.StringValue(output.PipelineDefinition)), &pipelineDefinition); err == nil {
+			definition = pipelineDefinition.Steps
+		}
+	}
+
+	return buildSageMakerPipelineProgress(steps, definition), nil
+}
+
+// buildSageMakerPipelineProgress returns the progress of SageMaker Pipelines
+// execution. The steps are expected to be ordered newest first. The total
+// number of steps includes the steps of the branches of condition steps that
+// were taken, or of the longest branch when the condition is not evaluated yet.
+func buildSageMakerPipelineProgress(steps []*sagemaker.PipelineExecutionStep, definition []*sageMakerPipelineStepDefinition) *sageMakerPipelineProgress {
+	progress := &sageMakerPipelineProgress{}
+	outcomes := make(map[string]string)
+	seen := make(map[string]bool)
+
+	for _, step := range steps {
+		name := aws.StringValue(step.StepName)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if step.Metadata != nil && step.Metadata.Condition != nil {
+			outcomes[name] = aws.StringValue(step.Metadata.Condition.Outcome)
+		}
+		switch aws.StringValue(step.StepStatus) {
+		case sagemaker.StepStatusSucceeded:
+			progress.Completed++
+		case sagemaker.StepStatusStarting, sagemaker.StepStatusExecuting:
+			progress.Executing = append(progress.Executing, name)
+		case sagemaker.StepStatusFailed:
+			// The earliest failed step is reported.
+			progress.FailedStep = name
+			progress.FailureReason = aws.StringValue(step.FailureReason)
+		case sagemaker.StepStatusStopped:
+			if progress.FailedStep == "" {
+				progress.FailedStep = name
+				progress.FailureReason = aws.StringValue(step.FailureReason)
+			}
+		}
+	}
+
+	progress.Total = countSageMakerPipelineSteps(definition, outcomes)
+	if progress.Total < len(seen) {
+		progress.Total = len(seen)
+	}
+	return progress
+}
+
+// countSageMakerPipelineSteps returns the number of steps of the pipeline
+// definition that are expected to run.
+func countSageMakerPipelineSteps(steps []*sageMakerPipelineStepDefinition, outcomes map[string]string) int {
+	var count int
+	for _, step := range steps {
+		count++
+		if step.Type != "Condition" {
+			continue
+		}
+		ifCount := countSageMakerPipelineSteps(step.Arguments.IfSteps, outcomes)
+		elseCount := countSageMakerPipelineSteps(step.Arguments.ElseSteps, outcomes)
+		switch outcomes[step.Name] {
+		case sagemaker.ConditionOutcomeTrue:
+			count += ifCount
+		case sagemaker.ConditionOutcomeFalse:
+			count += elseCount
+		default:
+			if ifCount > elseCount {
+				count += ifCount
+			} else {
+				count += elseCount
+			}
 		}
 	}
+	return count
 }
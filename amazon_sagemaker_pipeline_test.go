@@ -0,0 +1,89 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildSageMakerPipelineProgress(t *testing.T) {
+	definition := `{
+		"Steps": [
+			{"Name": "Preprocess", "Type": "Processing"},
+			{"Name": "Train", "Type": "Training"},
+			{"Name": "CheckAccuracy", "Type": "Condition", "Arguments": {
+				"IfSteps": [{"Name": "Register", "Type": "RegisterModel"}, {"Name": "Deploy", "Type": "Lambda"}],
+				"ElseSteps": [{"Name": "Notify", "Type": "Lambda"}]
+			}}
+		]
+	}`
+
+	var testcases = []struct {
+		name  string
+		steps []*sagemaker.PipelineExecutionStep
+		want  *sageMakerPipelineProgress
+	}{
+		{
+			name: "test executing step",
+			steps: []*sagemaker.PipelineExecutionStep{
+				{StepName: aws.String("Train"), StepStatus: aws.String("Executing")},
+				{StepName: aws.String("Preprocess"), StepStatus: aws.String("Succeeded")},
+			},
+			want: &sageMakerPipelineProgress{
+				Completed: 1,
+				Total:     5,
+				Executing: []string{"Train"},
+			},
+		},
+		{
+			name: "test failed step after false condition",
+			steps: []*sagemaker.PipelineExecutionStep{
+				{StepName: aws.String("Notify"), StepStatus: aws.String("Failed"), FailureReason: aws.String("ClientError: lambda timed out")},
+				{StepName: aws.String("CheckAccuracy"), StepStatus: aws.String("Succeeded"), Metadata: &sagemaker.PipelineExecutionStepMetadata{
+					Condition: &sagemaker.ConditionStepMetadata{Outcome: aws.String("False")},
+				}},
+				{StepName: aws.String("Train"), StepStatus: aws.String("Succeeded")},
+				{StepName: aws.String("Preprocess"), StepStatus: aws.String("Succeeded")},
+			},
+			want: &sageMakerPipelineProgress{
+				Completed:     3,
+				Total:         4,
+				FailedStep:    "Notify",
+				FailureReason: "ClientError: lambda timed out",
+			},
+		},
+	}
+
+	pipelineDefinition := struct {
+		Steps []*sageMakerPipelineStepDefinition `json:"Steps"`
+	}{}
+	if err := json.Unmarshal([]byte(definition), &pipelineDefinition); err != nil {
+		t.Fatalf("failed to parse pipeline definition: %v", err)
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildSageMakerPipelineProgress(tc.steps, pipelineDefinition.Steps)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
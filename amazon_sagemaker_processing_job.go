@@ -0,0 +1,69 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+// sageMakerProcessingJob is SageMaker processing job.
+var sageMakerProcessingJob = &sageMakerJobKind{
+	name: "processing",
+	newInput: func(req *PluginRequest, jobName string) (sageMakerJobInput, error) {
+		params := &sagemaker.CreateProcessingJobInput{}
+		if err := req.DecodeJobSpec(params); err != nil {
+			return nil, err
+		}
+		params.ProcessingJobName = aws.String(jobName)
+		return params, nil
+	},
+	create: func(sm *sagemaker.SageMaker, input sageMakerJobInput) (interface{}, string, error) {
+		output, err := sm.CreateProcessingJob(input.(*sagemaker.CreateProcessingJobInput))
+		if err != nil {
+			return nil, "", err
+		}
+		return output, aws.StringValue(output.ProcessingJobArn), nil
+	},
+	describe: func(sm *sagemaker.SageMaker, jobName string) (*sageMakerJobStatus, error) {
+		output, err := sm.DescribeProcessingJob(&sagemaker.DescribeProcessingJobInput{
+			ProcessingJobName: aws.String(jobName),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		outputs := map[string]string{
+			"job_arn": aws.StringValue(output.ProcessingJobArn),
+		}
+		if output.ProcessingOutputConfig != nil {
+			for _, o := range output.ProcessingOutputConfig.Outputs {
+				if o.S3Output == nil {
+					continue
+				}
+				outputs["output_"+aws.StringValue(o.OutputName)] = aws.StringValue(o.S3Output.S3Uri)
+			}
+		}
+		if output.ExitMessage != nil {
+			outputs["exit_message"] = aws.StringValue(output.ExitMessage)
+		}
+
+		return &sageMakerJobStatus{
+			Status:        aws.StringValue(output.ProcessingJobStatus),
+			FailureReason: aws.StringValue(output.FailureReason),
+			Outputs:       outputs,
+		}, nil
+	},
+}
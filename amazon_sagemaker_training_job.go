@@ -0,0 +1,73 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+// sageMakerTrainingJob is SageMaker training job.
+var sageMakerTrainingJob = &sageMakerJobKind{
+	name: "training",
+	newInput: func(req *PluginRequest, jobName string) (sageMakerJobInput, error) {
+		params := &sagemaker.CreateTrainingJobInput{}
+		if err := req.DecodeJobSpec(params); err != nil {
+			return nil, err
+		}
+		params.TrainingJobName = aws.String(jobName)
+		return params, nil
+	},
+	create: func(sm *sagemaker.SageMaker, input sageMakerJobInput) (interface{}, string, error) {
+		output, err := sm.CreateTrainingJob(input.(*sagemaker.CreateTrainingJobInput))
+		if err != nil {
+			return nil, "", err
+		}
+		return output, aws.StringValue(output.TrainingJobArn), nil
+	},
+	describe: func(sm *sagemaker.SageMaker, jobName string) (*sageMakerJobStatus, error) {
+		output, err := sm.DescribeTrainingJob(&sagemaker.DescribeTrainingJobInput{
+			TrainingJobName: aws.String(jobName),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		outputs := map[string]string{
+			"job_arn": aws.StringValue(output.TrainingJobArn),
+		}
+		if output.ModelArtifacts != nil {
+			outputs["model_artifacts"] = aws.StringValue(output.ModelArtifacts.S3ModelArtifacts)
+		}
+		if len(output.FinalMetricDataList) > 0 {
+			metrics := make(map[string]float64)
+			for _, m := range output.FinalMetricDataList {
+				metrics[aws.StringValue(m.MetricName)] = aws.Float64Value(m.Value)
+			}
+			if b, err := json.Marshal(metrics); err == nil {
+				outputs["final_metrics"] = string(b)
+			}
+		}
+
+		return &sageMakerJobStatus{
+			Status:          aws.StringValue(output.TrainingJobStatus),
+			SecondaryStatus: aws.StringValue(output.SecondaryStatus),
+			FailureReason:   aws.StringValue(output.FailureReason),
+			Outputs:         outputs,
+		}, nil
+	},
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+// sageMakerTransformJob is SageMaker batch transform job.
+var sageMakerTransformJob = &sageMakerJobKind{
+	name: "transform",
+	newInput: func(req *PluginRequest, jobName string) (sageMakerJobInput, error) {
+		params := &sagemaker.CreateTransformJobInput{}
+		if err := req.DecodeJobSpec(params); err != nil {
+			return nil, err
+		}
+		params.TransformJobName = aws.String(jobName)
+		return params, nil
+	},
+	create: func(sm *sagemaker.SageMaker, input sageMakerJobInput) (interface{}, string, error) {
+		output, err := sm.CreateTransformJob(input.(*sagemaker.CreateTransformJobInput))
+		if err != nil {
+			return nil, "", err
+		}
+		return output, aws.StringValue(output.TransformJobArn), nil
+	},
+	describe: func(sm *sagemaker.SageMaker, jobName string) (*sageMakerJobStatus, error) {
+		output, err := sm.DescribeTransformJob(&sagemaker.DescribeTransformJobInput{
+			TransformJobName: aws.String(jobName),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		outputs := map[string]string{
+			"job_arn": aws.StringValue(output.TransformJobArn),
+		}
+		if output.TransformOutput != nil {
+			outputs["output_path"] = aws.StringValue(output.TransformOutput.S3OutputPath)
+		}
+
+		return &sageMakerJobStatus{
+			Status:        aws.StringValue(output.TransformJobStatus),
+			FailureReason: aws.StringValue(output.FailureReason),
+			Outputs:       outputs,
+		}, nil
+	},
+}
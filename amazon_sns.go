@@ -0,0 +1,152 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"go.uber.org/zap"
+)
+
+// CheckIfSNSTopicExists checks whether a particular Amazon SNS topic exists.
+func (ex *ExecutorPlugin) CheckIfSNSTopicExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := sns.New(sess)
+
+	params := &sns.GetTopicAttributesInput{
+		TopicArn: aws.String(req.ResourceArn),
+	}
+
+	output, err := cli.GetTopicAttributes(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe amazon sns topic: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack amazon sns topic check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// PublishSNSMessage publishes a message to Amazon SNS topic.
+func (ex *ExecutorPlugin) PublishSNSMessage(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := sns.New(sess)
+
+	correlationID := req.CorrelationID
+	if correlationID == "" {
+		correlationID = workflowID
+	}
+
+	params, err := buildSNSPublishInput(req, correlationID)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	output, err := cli.Publish(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to publish amazon sns message: %s", err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("published amazon sns message",
+		zap.String("plugin_name", app.Name),
+		zap.String("topic_arn", req.ResourceArn),
+		zap.String("message_id", aws.StringValue(output.MessageId)),
+	)
+
+	return &PluginResponse{
+		Message: fmt.Sprintf("published amazon sns message %s", aws.StringValue(output.MessageId)),
+		Status:  1,
+		Outputs: map[string]string{
+			"message_id":     aws.StringValue(output.MessageId),
+			"correlation_id": correlationID,
+		},
+	}
+}
+
+// buildSNSPublishInput returns the input for publishing the message of the
+// request with the correlation ID attribute.
+func buildSNSPublishInput(req *PluginRequest, correlationID string) (*sns.PublishInput, error) {
+	body := req.MessageBody
+	if body == "" {
+		b, err := json.Marshal(req.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build amazon sns message: %s", err)
+		}
+		body = string(b)
+	}
+
+	params := &sns.PublishInput{
+		TopicArn: aws.String(req.ResourceArn),
+		Message:  aws.String(body),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			sqsCorrelationAttributeName: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(correlationID),
+			},
+		},
+	}
+	for k, v := range req.MessageAttributes {
+		params.MessageAttributes[k] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+	if req.Subject != "" {
+		params.Subject = aws.String(req.Subject)
+	}
+	if req.MessageGroupID != "" {
+		params.MessageGroupId = aws.String(req.MessageGroupID)
+	}
+	if req.MessageDedupID != "" {
+		params.MessageDeduplicationId = aws.String(req.MessageDedupID)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildSNSPublishInput(t *testing.T) {
+	var testcases = []struct {
+		name string
+		req  *PluginRequest
+		want *sns.PublishInput
+	}{
+		{
+			name: "test message body with attributes",
+			req: &PluginRequest{
+				ResourceArn: "arn:aws:sns:us-east-1:100000000002:orders",
+				MessageBody: "order received",
+				Subject:     "orders",
+				MessageAttributes: map[string]string{
+					"event_type": "created",
+				},
+			},
+			want: &sns.PublishInput{
+				TopicArn: aws.String("arn:aws:sns:us-east-1:100000000002:orders"),
+				Message:  aws.String("order received"),
+				Subject:  aws.String("orders"),
+				MessageAttributes: map[string]*sns.MessageAttributeValue{
+					"correlation_id": {
+						DataType:    aws.String("String"),
+						StringValue: aws.String("wf-1234"),
+					},
+					"event_type": {
+						DataType:    aws.String("String"),
+						StringValue: aws.String("created"),
+					},
+				},
+			},
+		},
+		{
+			name: "test message from parameters to fifo topic",
+			req: &PluginRequest{
+				ResourceArn: "arn:aws:sns:us-east-1:100000000002:orders.fifo",
+				Parameters: map[string]interface{}{
+					"order_id": "1234",
+				},
+				MessageGroupID: "orders",
+				MessageDedupID: "1234",
+			},
+			want: &sns.PublishInput{
+				TopicArn: aws.String("arn:aws:sns:us-east-1:100000000002:orders.fifo"),
+				Message:  aws.String(`{"order_id":"1234"}`),
+				MessageAttributes: map[string]*sns.MessageAttributeValue{
+					"correlation_id": {
+						DataType:    aws.String("String"),
+						StringValue: aws.String("wf-1234"),
+					},
+				},
+				MessageGroupId:         aws.String("orders"),
+				MessageDeduplicationId: aws.String("1234"),
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := buildSNSPublishInput(tc.req, "wf-1234")
+			if err != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// sqsCorrelationAttributeName is the name of the message attribute
+	// carrying the correlation ID of a message.
+	sqsCorrelationAttributeName = "correlation_id"
+	// sqsMaxBatchSize is the maximum number of messages in SendMessageBatch call.
+	sqsMaxBatchSize = 10
+)
+
+// CheckIfSQSQueueExists checks whether a particular Amazon SQS queue exists.
+func (ex *ExecutorPlugin) CheckIfSQSQueueExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := sqs.New(sess)
+
+	queueURL, err := getSQSQueueURL(cli, req.AccountID, req.QueueName)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: queueURL,
+		Status:  1,
+	}
+}
+
+// SendSQSMessages sends one or more messages to Amazon SQS queue.
+func (ex *ExecutorPlugin) SendSQSMessages(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := sqs.New(sess)
+
+	queueURL, err := getSQSQueueURL(cli, req.AccountID, req.QueueName)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	correlationID := req.CorrelationID
+	if correlationID == "" {
+		correlationID = workflowID
+	}
+
+	attrs := buildSQSMessageAttributes(req.MessageAttributes, correlationID)
+
+	if len(req.MessageBatch) == 0 {
+		body := req.MessageBody
+		if body == "" {
+			b, err := json.Marshal(req.Parameters)
+			if err != nil {
+				return &PluginResponse{
+					ExecutionError: fmt.Errorf("failed to build amazon sqs message body: %s", err),
+					Status:         2,
+				}
+			}
+			body = string(b)
+		}
+
+		params := &sqs.SendMessageInput{
+			QueueUrl:          aws.String(queueURL),
+			MessageBody:       aws.String(body),
+			MessageAttributes: attrs,
+		}
+		if req.MessageGroupID != "" {
+			params.MessageGroupId = aws.String(req.MessageGroupID)
+		}
+		if req.MessageDedupID != "" {
+			params.MessageDeduplicationId = aws.String(req.MessageDedupID)
+		}
+
+		output, err := cli.SendMessage(params)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to send amazon sqs message: %s", err),
+				Status:         2,
+			}
+		}
+
+		ex.Logger.Info("sent amazon sqs message",
+			zap.String("plugin_name", app.Name),
+			zap.String("queue_url", queueURL),
+			zap.String("message_id", aws.StringValue(output.MessageId)),
+		)
+
+		return &PluginResponse{
+			Message: fmt.Sprintf("sent amazon sqs message %s", aws.StringValue(output.MessageId)),
+			Status:  1,
+			Outputs: map[string]string{
+				"message_id":     aws.StringValue(output.MessageId),
+				"correlation_id": correlationID,
+			},
+		}
+	}
+
+	var messageIDs []string
+	var failures []string
+
+	for start := 0; start < len(req.MessageBatch); start += sqsMaxBatchSize {
+		end := start + sqsMaxBatchSize
+		if end > len(req.MessageBatch) {
+			end = len(req.MessageBatch)
+		}
+		params := &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(queueURL),
+		}
+		for i := start; i < end; i++ {
+			entry := &sqs.SendMessageBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				MessageBody:       aws.String(req.MessageBatch[i]),
+				MessageAttributes: attrs,
+			}
+			if req.MessageGroupID != "" {
+				entry.MessageGroupId = aws.String(req.MessageGroupID)
+			}
+			if req.MessageDedupID != "" {
+				entry.MessageDeduplicationId = aws.String(fmt.Sprintf("%s-%d", req.MessageDedupID, i))
+			}
+			params.Entries = append(params.Entries, entry)
+		}
+
+		output, err := cli.SendMessageBatch(params)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to send amazon sqs message batch: %s", err),
+				Status:         2,
+			}
+		}
+		for _, entry := range output.Successful {
+			messageIDs = append(messageIDs, aws.StringValue(entry.MessageId))
+		}
+		for _, entry := range output.Failed {
+			failures = append(failures, fmt.Sprintf("%s: %s (%s)",
+				aws.StringValue(entry.Id), aws.StringValue(entry.Code), aws.StringValue(entry.Message)))
+		}
+	}
+
+	ex.Logger.Info("sent amazon sqs message batch",
+		zap.String("plugin_name", app.Name),
+		zap.String("queue_url", queueURL),
+		zap.Int("sent_count", len(messageIDs)),
+		zap.Int("failed_count", len(failures)),
+	)
+
+	outputs := map[string]string{
+		"message_ids":    strings.Join(messageIDs, ","),
+		"correlation_id": correlationID,
+		"sent_count":     strconv.Itoa(len(messageIDs)),
+		"failed_count":   strconv.Itoa(len(failures)),
+	}
+
+	if len(failures) > 0 {
+		return &PluginResponse{
+			Message: fmt.Sprintf("failed to send %d amazon sqs messages: %s", len(failures), strings.Join(failures, "; ")),
+			Status:  2,
+			Outputs: outputs,
+		}
+	}
+
+	return &PluginResponse{
+		Message: fmt.Sprintf("sent %d amazon sqs messages", len(messageIDs)),
+		Status:  1,
+		Outputs: outputs,
+	}
+}
+
+// AwaitSQSMessage polls Amazon SQS reply queue for a message with the
+// correlation ID of the workflow.
+//
+// Amazon SQS cannot filter messages by attribute, so the messages correlated
+// with other workflows are received and released. Every receive increments
+// the receive count of the message, and the messages exceeding the
+// maxReceiveCount of the redrive policy of the queue move to its dead-letter
+// queue. Therefore, the reply queue is expected to be dedicated to a single
+// correlation ID, e.g. created per workflow. The plugin warns when it
+// receives messages correlated with other workflows.
+func (ex *ExecutorPlugin) AwaitSQSMessage(req *PluginRequest, workflowID string) *PluginResponse {
+	correlationID := req.CorrelationID
+	if correlationID == "" {
+		correlationID = workflowID
+	}
+
+	wf, exists := ex.getWorkflow(req, workflowID)
+	if !exists {
+		wf = &PluginWorkflow{
+			ID:        correlationID,
+			StartedAt: time.Now().UTC(),
+		}
+		ex.setWorkflow(req, workflowID, wf)
+		ex.Logger.Info("started awaiting amazon sqs message",
+			zap.String("plugin_name", app.Name),
+			zap.String("queue_name", req.ReplyQueueName),
+			zap.String("correlation_id", correlationID),
+		)
+	}
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := sqs.New(sess)
+
+	queueURL, err := getSQSQueueURL(cli, req.AccountID, req.ReplyQueueName)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	params := &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(queueURL),
+		MaxNumberOfMessages:   aws.Int64(10),
+		WaitTimeSeconds:       aws.Int64(10),
+		VisibilityTimeout:     aws.Int64(30),
+		MessageAttributeNames: aws.StringSlice([]string{"All"}),
+		AttributeNames:        aws.StringSlice([]string{sqs.MessageSystemAttributeNameApproximateReceiveCount}),
+	}
+
+	output, err := cli.ReceiveMessage(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to receive amazon sqs messages: %s", err),
+			Status:         2,
+		}
+	}
+
+	var matched *sqs.Message
+	maxReceiveCount := -1
+	for _, msg := range output.Messages {
+		if matched == nil && matchSQSMessage(msg, wf.ID) {
+			matched = msg
+			continue
+		}
+		if maxReceiveCount < 0 {
+			maxReceiveCount = getSQSMaxReceiveCount(cli, queueURL)
+		}
+		ex.Logger.Warn("received amazon sqs message correlated with other workflow, the reply queue should not be shared",
+			zap.String("plugin_name", app.Name),
+			zap.String("queue_url", queueURL),
+			zap.String("message_id", aws.StringValue(msg.MessageId)),
+			zap.Int("receive_count", getSQSMessageReceiveCount(msg)),
+			zap.Int("max_receive_count", maxReceiveCount),
+			zap.Bool("moves_to_dead_letter_queue", isSQSMessageRedriven(msg, maxReceiveCount)),
+		)
+		// Release the messages correlated with other workflows.
+		if _, err := cli.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(queueURL),
+			ReceiptHandle:     msg.ReceiptHandle,
+			VisibilityTimeout: aws.Int64(0),
+		}); err != nil {
+			ex.Logger.Warn("failed to release amazon sqs message",
+				zap.String("plugin_name", app.Name),
+				zap.String("message_id", aws.StringValue(msg.MessageId)),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if matched != nil {
+		if _, err := cli.DeleteMessage(&sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(queueURL),
+			ReceiptHandle: matched.ReceiptHandle,
+		}); err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to delete amazon sqs message: %s", err),
+				Status:         2,
+			}
+		}
+
+		ex.Logger.Info("received amazon sqs message",
+			zap.String("plugin_name", app.Name),
+			zap.String("queue_url", queueURL),
+			zap.String("correlation_id", wf.ID),
+			zap.String("message_id", aws.StringValue(matched.MessageId)),
+		)
+
+		return &PluginResponse{
+			Message: fmt.Sprintf("received amazon sqs message %s", aws.StringValue(matched.MessageId)),
+			Status:  1,
+			Outputs: map[string]string{
+				"message_id":     aws.StringValue(matched.MessageId),
+				"message_body":   aws.StringValue(matched.Body),
+				"correlation_id": wf.ID,
+			},
+		}
+	}
+
+	if req.WaitTimeout > 0 && time.Since(wf.StartedAt) > time.Duration(req.WaitTimeout)*time.Second {
+		return &PluginResponse{
+			ExecutionError:  fmt.Errorf("timed out after %ds awaiting amazon sqs message with correlation id %s", req.WaitTimeout, wf.ID),
+			Status:          2,
+			FailureCategory: FailureTimeout,
+		}
+	}
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("awaiting amazon sqs message with correlation id %s", wf.ID),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 10 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// getSQSQueueURL returns the URL of Amazon SQS queue.
+func getSQSQueueURL(cli *sqs.SQS, accountID, queueName string) (string, error) {
+	output, err := cli.GetQueueUrl(&sqs.GetQueueUrlInput{
+		QueueName:              aws.String(queueName),
+		QueueOwnerAWSAccountId: aws.String(accountID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get amazon sqs queue url: %s", err)
+	}
+	return aws.StringValue(output.QueueUrl), nil
+}
+
+// getSQSMaxReceiveCount returns the maxReceiveCount of the redrive policy of
+// Amazon SQS queue, or zero when the queue has no dead-letter queue.
+func getSQSMaxReceiveCount(cli *sqs.SQS, queueURL string) int {
+	output, err := cli.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: aws.StringSlice([]string{sqs.QueueAttributeNameRedrivePolicy}),
+	})
+	if err != nil {
+		return 0
+	}
+	return parseSQSMaxReceiveCount(aws.StringValue(output.Attributes[sqs.QueueAttributeNameRedrivePolicy]))
+}
+
+// parseSQSMaxReceiveCount returns the maxReceiveCount of the redrive policy,
+// or zero when the policy is empty or malformed.
+func parseSQSMaxReceiveCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	policy := struct {
+		MaxReceiveCount json.Number `json:"maxReceiveCount"`
+	}{}
+	if err := json.Unmarshal([]byte(s), &policy); err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(policy.MaxReceiveCount.String())
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// getSQSMessageReceiveCount returns the approximate number of times the
+// message was received.
+func getSQSMessageReceiveCount(msg *sqs.Message) int {
+	n, _ := strconv.Atoi(aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+	return n
+}
+
+// isSQSMessageRedriven checks whether the message moves to the dead-letter
+// queue when received again, i.e. it was received maxReceiveCount times.
+func isSQSMessageRedriven(msg *sqs.Message, maxReceiveCount int) bool {
+	if maxReceiveCount <= 0 {
+		return false
+	}
+	return getSQSMessageReceiveCount(msg) >= maxReceiveCount
+}
+
+// buildSQSMessageAttributes converts string attributes to Amazon SQS message
+// attributes and adds the correlation ID attribute.
+func buildSQSMessageAttributes(m map[string]string, correlationID string) map[string]*sqs.MessageAttributeValue {
+	attrs := make(map[string]*sqs.MessageAttributeValue)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs[k] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(m[k]),
+		}
+	}
+	attrs[sqsCorrelationAttributeName] = &sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(correlationID),
+	}
+	return attrs
+}
+
+// matchSQSMessage checks whether the message carries the correlation ID
+// either as a message attribute or as a field of its JSON body.
+func matchSQSMessage(msg *sqs.Message, correlationID string) bool {
+	if attr, exists := msg.MessageAttributes[sqsCorrelationAttributeName]; exists {
+		return aws.StringValue(attr.StringValue) == correlationID
+	}
+	body := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(aws.StringValue(msg.Body)), &body); err != nil {
+		return false
+	}
+	if v, ok := body[sqsCorrelationAttributeName].(string); ok {
+		return v == correlationID
+	}
+	return false
+}
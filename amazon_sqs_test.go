@@ -0,0 +1,166 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchSQSMessage(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		input *sqs.Message
+		want  bool
+	}{
+		{
+			name: "test message with matching correlation id attribute",
+			input: &sqs.Message{
+				Body: aws.String("done"),
+				MessageAttributes: map[string]*sqs.MessageAttributeValue{
+					"correlation_id": {
+						DataType:    aws.String("String"),
+						StringValue: aws.String("27c01e7c-9d93-450f-a001-c64d649aac99"),
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "test message with other correlation id attribute",
+			input: &sqs.Message{
+				Body: aws.String(`{"correlation_id": "27c01e7c-9d93-450f-a001-c64d649aac99"}`),
+				MessageAttributes: map[string]*sqs.MessageAttributeValue{
+					"correlation_id": {
+						DataType:    aws.String("String"),
+						StringValue: aws.String("1018894b-ede2-4b38-b258-e707e133b839"),
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "test message with matching correlation id in body",
+			input: &sqs.Message{
+				Body: aws.String(`{"correlation_id": "27c01e7c-9d93-450f-a001-c64d649aac99", "status": "done"}`),
+			},
+			want: true,
+		},
+		{
+			name: "test message with non-json body",
+			input: &sqs.Message{
+				Body: aws.String("27c01e7c-9d93-450f-a001-c64d649aac99"),
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := matchSQSMessage(tc.input, "27c01e7c-9d93-450f-a001-c64d649aac99")
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestParseSQSMaxReceiveCount(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{
+			name: "test queue without redrive policy",
+			want: 0,
+		},
+		{
+			name:  "test redrive policy with numeric max receive count",
+			input: `{"deadLetterTargetArn":"arn:aws:sqs:us-west-2:100000000002:MyReplyQueueDLQ","maxReceiveCount":5}`,
+			want:  5,
+		},
+		{
+			name:  "test redrive policy with string max receive count",
+			input: `{"deadLetterTargetArn":"arn:aws:sqs:us-west-2:100000000002:MyReplyQueueDLQ","maxReceiveCount":"3"}`,
+			want:  3,
+		},
+		{
+			name:  "test malformed redrive policy",
+			input: `{"maxReceiveCount":`,
+			want:  0,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseSQSMaxReceiveCount(tc.input)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestIsSQSMessageRedriven(t *testing.T) {
+	var testcases = []struct {
+		name            string
+		receiveCount    string
+		maxReceiveCount int
+		want            bool
+	}{
+		{
+			name:            "test message on queue without dead-letter queue",
+			receiveCount:    "100",
+			maxReceiveCount: 0,
+			want:            false,
+		},
+		{
+			name:            "test message received fewer times than max receive count",
+			receiveCount:    "2",
+			maxReceiveCount: 3,
+			want:            false,
+		},
+		{
+			name:            "test message received max receive count times",
+			receiveCount:    "3",
+			maxReceiveCount: 3,
+			want:            true,
+		},
+		{
+			name:            "test message without receive count",
+			maxReceiveCount: 3,
+			want:            false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &sqs.Message{
+				Attributes: map[string]*string{},
+			}
+			if tc.receiveCount != "" {
+				msg.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount] = aws.String(tc.receiveCount)
+			}
+			got := isSQSMessageRedriven(msg, tc.maxReceiveCount)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultTokenFile is the path of the token that Argo mounts into the plugin
+// sidecar. The agent sends the same token with template.execute requests.
+const defaultTokenFile = "/var/run/argo/token"
+
+// tokenAuthenticator authenticates requests with the bearer token stored in
+// a file. The token is reloaded when the file changes, e.g. on rotation.
+type tokenAuthenticator struct {
+	sync.RWMutex
+	path    string
+	token   []byte
+	modTime time.Time
+	size    int64
+}
+
+// newTokenAuthenticator returns the authenticator for the token file.
+func newTokenAuthenticator(path string) *tokenAuthenticator {
+	return &tokenAuthenticator{path: path}
+}
+
+// getToken returns the token, reloading it when the file has changed.
+func (a *tokenAuthenticator) getToken() ([]byte, error) {
+	fi, err := os.Stat(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat token file: %s", err)
+	}
+
+	a.RLock()
+	if a.token != nil && fi.ModTime().Equal(a.modTime) && fi.Size() == a.size {
+		token := a.token
+		a.RUnlock()
+		return token, nil
+	}
+	a.RUnlock()
+
+	b, err := os.ReadFile(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %s", err)
+	}
+	token := []byte(strings.TrimSpace(string(b)))
+	if len(token) == 0 {
+		return nil, fmt.Errorf("token file %s is empty", a.path)
+	}
+
+	a.Lock()
+	a.token = token
+	a.modTime = fi.ModTime()
+	a.size = fi.Size()
+	a.Unlock()
+	return token, nil
+}
+
+// authenticate returns the HTTP status code for the request. The requests
+// without bearer token are unauthorized, and the requests with the token
+// other than the one in the file are forbidden.
+func (a *tokenAuthenticator) authenticate(req *http.Request) (int, error) {
+	header := req.Header.Get("Authorization")
+	if header == "" {
+		return http.StatusUnauthorized, fmt.Errorf("authorization header not found")
+	}
+	scheme, credentials, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(credentials) == "" {
+		return http.StatusUnauthorized, fmt.Errorf("authorization header is not bearer token")
+	}
+
+	token, err := a.getToken()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(credentials)), token) != 1 {
+		return http.StatusForbidden, fmt.Errorf("bearer token mismatch")
+	}
+	return http.StatusOK, nil
+}
+
+// handleAuthentication wraps the handler with the authentication of the
+// bearer token, unless the authentication is disabled.
+func handleAuthentication(ex *ExecutorPlugin, next http.HandlerFunc) http.HandlerFunc {
+	if ex.AuthDisabled {
+		ex.Logger.Warn("authentication is disabled", zap.String("plugin_name", app.Name))
+		return next
+	}
+
+	tokenFile := ex.TokenFile
+	if tokenFile == "" {
+		tokenFile = defaultTokenFile
+	}
+	auth := newTokenAuthenticator(tokenFile)
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		code, err := auth.authenticate(req)
+		if err != nil {
+			metricRequests.WithLabelValues("", "", "unauthenticated").Inc()
+			ex.Logger.Warn(
+				"failed to authenticate request",
+				zap.String("plugin_name", app.Name),
+				zap.String("remote_addr", req.RemoteAddr),
+				zap.Int("status_code", code),
+				zap.Error(err),
+			)
+			if code == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		next(w, req)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeTestTokenFile writes the token to the file. The modification time is
+// moved forward, so that it changes on filesystems with coarse timestamps
+// and the authenticator reloads the token.
+func writeTestTokenFile(t *testing.T, path, token string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(token), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	tokenFilePath := filepath.Join(t.TempDir(), "token")
+	writeTestTokenFile(t, tokenFilePath, "foo\n")
+	auth := newTokenAuthenticator(tokenFilePath)
+
+	var testcases = []struct {
+		name   string
+		header string
+		token  string
+		want   int
+	}{
+		{
+			name: "test request without authorization header",
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:   "test request with basic authorization header",
+			header: "Basic Zm9vOmJhcg==",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "test request with invalid bearer token",
+			header: "Bearer bar",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "test request with valid bearer token",
+			header: "Bearer foo",
+			want:   http.StatusOK,
+		},
+		{
+			name:   "test request with previous bearer token after rotation",
+			header: "Bearer foo",
+			token:  "bar",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "test request with rotated bearer token",
+			header: "Bearer bar",
+			want:   http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.token != "" {
+				writeTestTokenFile(t, tokenFilePath, tc.token)
+			}
+			req, err := http.NewRequest("POST", "/api/v1/template.execute", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			got, _ := auth.authenticate(req)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,486 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// cloudFormationPhaseChangeSet is the phase of waiting for the change set
+	// to be created.
+	cloudFormationPhaseChangeSet = "CHANGE_SET"
+	// cloudFormationPhaseStack is the phase of waiting for the stack to
+	// reach terminal status after the change set execution.
+	cloudFormationPhaseStack = "STACK"
+)
+
+// CheckIfCloudFormationTemplateIsValid validates AWS CloudFormation template.
+func (ex *ExecutorPlugin) CheckIfCloudFormationTemplateIsValid(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := cloudformation.New(sess)
+
+	params := &cloudformation.ValidateTemplateInput{}
+	if req.TemplateBody != "" {
+		params.TemplateBody = aws.String(req.TemplateBody)
+	} else {
+		params.TemplateURL = aws.String(req.TemplateURL)
+	}
+
+	output, err := cli.ValidateTemplate(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to validate aws cloudformation template: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws cloudformation template check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartCloudFormationStackDeployment creates AWS CloudFormation change set
+// for the stack. The change set creates the stack when it does not exist,
+// and updates it otherwise.
+func (ex *ExecutorPlugin) StartCloudFormationStackDeployment(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := cloudformation.New(sess)
+
+	stack, err := describeCloudFormationStack(cli, req.StackName)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+	changeSetType, err := getCloudFormationChangeSetType(req.StackName, stack)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError:  err,
+			Status:          2,
+			FailureCategory: FailureUserError,
+		}
+	}
+
+	changeSetName := fmt.Sprintf("argo-%s-%d", workflowID, time.Now().Unix())
+
+	params := &cloudformation.CreateChangeSetInput{
+		StackName:     aws.String(req.StackName),
+		ChangeSetName: aws.String(changeSetName),
+		ChangeSetType: aws.String(changeSetType),
+		Parameters:    buildCloudFormationParameters(req.Parameters),
+		Tags:          buildCloudFormationTags(req.StackTags),
+	}
+	if req.TemplateBody != "" {
+		params.TemplateBody = aws.String(req.TemplateBody)
+	} else {
+		params.TemplateURL = aws.String(req.TemplateURL)
+	}
+	if len(req.Capabilities) > 0 {
+		params.Capabilities = aws.StringSlice(req.Capabilities)
+	}
+
+	output, err := cli.CreateChangeSet(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws cloudformation change set: %s", err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("created aws cloudformation change set",
+		zap.String("plugin_name", app.Name),
+		zap.String("stack_name", req.StackName),
+		zap.String("change_set_type", changeSetType),
+		zap.String("change_set_id", aws.StringValue(output.Id)),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID:     aws.StringValue(output.Id),
+		Status: cloudFormationPhaseChangeSet,
+	})
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("created aws cloudformation change set %s for stack %s", changeSetName, req.StackName),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 15 * time.Second,
+		},
+		Status: 3,
+		Outputs: map[string]string{
+			"change_set_id": aws.StringValue(output.Id),
+			"stack_id":      aws.StringValue(output.StackId),
+		},
+	}
+}
+
+// CheckCloudFormationStackDeployment checks the status of AWS CloudFormation
+// change set, executes it once created, and then checks the status of the stack.
+func (ex *ExecutorPlugin) CheckCloudFormationStackDeployment(req *PluginRequest, wf *PluginWorkflow) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := cloudformation.New(sess)
+
+	if wf.Status == cloudFormationPhaseChangeSet {
+		return ex.checkCloudFormationChangeSet(cli, req, wf)
+	}
+
+	// The stack is described by its ID, because the stack whose creation
+	// rolled back is deleted and no longer found by its name.
+	stack, err := describeCloudFormationStack(cli, wf.ID)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+	if stack == nil {
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws cloudformation stack %s does not exist", req.StackName),
+			Status:  4,
+		}
+	}
+
+	status := aws.StringValue(stack.StackStatus)
+
+	ex.Logger.Info("checking aws cloudformation stack",
+		zap.String("plugin_name", app.Name),
+		zap.String("stack_name", req.StackName),
+		zap.String("stack_status", status),
+	)
+
+	outputs := buildCloudFormationStackOutputs(stack)
+
+	switch status {
+	case cloudformation.StackStatusCreateComplete, cloudformation.StackStatusUpdateComplete, cloudformation.StackStatusImportComplete:
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws cloudformation stack %s is %s", req.StackName, status),
+			Status:  1,
+			Outputs: outputs,
+		}
+	case cloudformation.StackStatusCreateFailed,
+		cloudformation.StackStatusRollbackFailed,
+		cloudformation.StackStatusRollbackComplete,
+		cloudformation.StackStatusDeleteFailed,
+		cloudformation.StackStatusDeleteComplete,
+		cloudformation.StackStatusUpdateFailed,
+		cloudformation.StackStatusUpdateRollbackFailed,
+		cloudformation.StackStatusUpdateRollbackComplete,
+		cloudformation.StackStatusImportRollbackFailed,
+		cloudformation.StackStatusImportRollbackComplete:
+		msg := fmt.Sprintf("aws cloudformation stack %s is %s", req.StackName, status)
+		events, err := listCloudFormationStackEvents(cli, wf.ID, wf.StartedAt)
+		if err != nil {
+			ex.Logger.Warn("failed to describe aws cloudformation stack events",
+				zap.String("plugin_name", app.Name),
+				zap.String("stack_name", req.StackName),
+				zap.Error(err),
+			)
+		} else if event := findFirstFailedCloudFormationEvent(events); event != nil {
+			msg += fmt.Sprintf(": %s %s: %s",
+				aws.StringValue(event.LogicalResourceId),
+				aws.StringValue(event.ResourceStatus),
+				aws.StringValue(event.ResourceStatusReason),
+			)
+		}
+		return &PluginResponse{
+			Message: msg,
+			Status:  4,
+			Outputs: outputs,
+		}
+	default:
+		// Covers In Progress, including rollback in progress
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws cloudformation stack %s is %s", req.StackName, status),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 30 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+}
+
+// checkCloudFormationChangeSet checks the status of AWS CloudFormation change
+// set and executes it once it has been created.
+func (ex *ExecutorPlugin) checkCloudFormationChangeSet(cli *cloudformation.CloudFormation, req *PluginRequest, wf *PluginWorkflow) *PluginResponse {
+	output, err := cli.DescribeChangeSet(&cloudformation.DescribeChangeSetInput{
+		ChangeSetName: aws.String(wf.ID),
+	})
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws cloudformation change set: %s", err),
+			Status:         2,
+		}
+	}
+
+	status := aws.StringValue(output.Status)
+	reason := aws.StringValue(output.StatusReason)
+
+	ex.Logger.Info("checking aws cloudformation change set",
+		zap.String("plugin_name", app.Name),
+		zap.String("stack_name", req.StackName),
+		zap.String("change_set_id", wf.ID),
+		zap.String("change_set_status", status),
+	)
+
+	switch status {
+	case cloudformation.ChangeSetStatusCreateComplete:
+	case cloudformation.ChangeSetStatusFailed:
+		if strings.Contains(reason, "didn't contain changes") || strings.Contains(reason, "No updates are to be performed") {
+			// The stack is already up to date.
+			if _, err := cli.DeleteChangeSet(&cloudformation.DeleteChangeSetInput{
+				ChangeSetName: aws.String(wf.ID),
+			}); err != nil {
+				ex.Logger.Warn("failed to delete empty aws cloudformation change set",
+					zap.String("plugin_name", app.Name),
+					zap.String("change_set_id", wf.ID),
+					zap.Error(err),
+				)
+			}
+			stack, err := describeCloudFormationStack(cli, req.StackName)
+			if err != nil {
+				return &PluginResponse{
+					ExecutionError: err,
+					Status:         2,
+				}
+			}
+			var outputs map[string]string
+			if stack != nil {
+				outputs = buildCloudFormationStackOutputs(stack)
+			}
+			return &PluginResponse{
+				Message: fmt.Sprintf("aws cloudformation stack %s has no changes", req.StackName),
+				Status:  1,
+				Outputs: outputs,
+			}
+		}
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws cloudformation change set for stack %s is %s: %s", req.StackName, status, reason),
+			Status:  4,
+		}
+	default:
+		// Covers Create Pending and Create In Progress
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws cloudformation change set for stack %s is %s", req.StackName, status),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 15 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+
+	startedAt := time.Now().UTC()
+	if _, err := cli.ExecuteChangeSet(&cloudformation.ExecuteChangeSetInput{
+		ChangeSetName: aws.String(wf.ID),
+	}); err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to execute aws cloudformation change set: %s", err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("executed aws cloudformation change set",
+		zap.String("plugin_name", app.Name),
+		zap.String("stack_name", req.StackName),
+		zap.String("change_set_id", wf.ID),
+		zap.Int("change_count", len(output.Changes)),
+	)
+
+	wf.ID = aws.StringValue(output.StackId)
+	wf.Status = cloudFormationPhaseStack
+	wf.StartedAt = startedAt
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("executed aws cloudformation change set with %d changes for stack %s", len(output.Changes), req.StackName),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 30 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// getCloudFormationChangeSetType returns the type of the change set for the
+// stack. The change set creates the stack when it does not exist, and updates
+// it otherwise. The stack whose creation rolled back cannot be updated, and
+// must be deleted first.
+func getCloudFormationChangeSetType(stackName string, stack *cloudformation.Stack) (string, error) {
+	if stack == nil {
+		return cloudformation.ChangeSetTypeCreate, nil
+	}
+	switch status := aws.StringValue(stack.StackStatus); status {
+	case cloudformation.StackStatusReviewInProgress:
+		return cloudformation.ChangeSetTypeCreate, nil
+	case cloudformation.StackStatusRollbackComplete, cloudformation.StackStatusRollbackFailed:
+		return "", fmt.Errorf("aws cloudformation stack %s is %s and must be deleted before it can be deployed", stackName, status)
+	}
+	return cloudformation.ChangeSetTypeUpdate, nil
+}
+
+// describeCloudFormationStack returns AWS CloudFormation stack. It returns
+// nil when the stack does not exist.
+func describeCloudFormationStack(cli *cloudformation.CloudFormation, stackName string) (*cloudformation.Stack, error) {
+	output, err := cli.DescribeStacks(&cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ValidationError" && strings.Contains(aerr.Message(), "does not exist") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe aws cloudformation stack: %s", err)
+	}
+	if len(output.Stacks) == 0 {
+		return nil, nil
+	}
+	return output.Stacks[0], nil
+}
+
+// listCloudFormationStackEvents returns the events of AWS CloudFormation
+// stack that occurred since the time provided, newest first.
+func listCloudFormationStackEvents(cli *cloudformation.CloudFormation, stackName string, since time.Time) ([]*cloudformation.StackEvent, error) {
+	var events []*cloudformation.StackEvent
+	err := cli.DescribeStackEventsPages(&cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackName),
+	}, func(page *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+		for _, event := range page.StackEvents {
+			if aws.TimeValue(event.Timestamp).Before(since) {
+				return false
+			}
+			events = append(events, event)
+		}
+		return true
+	})
+	return events, err
+}
+
+// findFirstFailedCloudFormationEvent returns the earliest failed resource
+// event. The events are expected to be ordered newest first.
+func findFirstFailedCloudFormationEvent(events []*cloudformation.StackEvent) *cloudformation.StackEvent {
+	var first *cloudformation.StackEvent
+	for _, event := range events {
+		if !strings.HasSuffix(aws.StringValue(event.ResourceStatus), "_FAILED") {
+			continue
+		}
+		if aws.StringValue(event.ResourceStatusReason) == "" {
+			continue
+		}
+		first = event
+	}
+	return first
+}
+
+// buildCloudFormationParameters converts the parameters of the request to
+// AWS CloudFormation stack parameters.
+func buildCloudFormationParameters(m map[string]interface{}) []*cloudformation.Parameter {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []*cloudformation.Parameter
+	for _, k := range keys {
+		var value string
+		switch v := m[k].(type) {
+		case string:
+			value = v
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			value = strings.Join(items, ",")
+		default:
+			value = fmt.Sprint(v)
+		}
+		params = append(params, &cloudformation.Parameter{
+			ParameterKey:   aws.String(k),
+			ParameterValue: aws.String(value),
+		})
+	}
+	return params
+}
+
+// buildCloudFormationTags converts the tags of the request to AWS
+// CloudFormation stack tags.
+func buildCloudFormationTags(m map[string]string) []*cloudformation.Tag {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var tags []*cloudformation.Tag
+	for _, k := range keys {
+		tags = append(tags, &cloudformation.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(m[k]),
+		})
+	}
+	return tags
+}
+
+// buildCloudFormationStackOutputs converts the outputs of AWS CloudFormation
+// stack to the outputs of the response.
+func buildCloudFormationStackOutputs(stack *cloudformation.Stack) map[string]string {
+	outputs := map[string]string{
+		"stack_id":     aws.StringValue(stack.StackId),
+		"stack_status": aws.StringValue(stack.StackStatus),
+	}
+	for _, o := range stack.Outputs {
+		outputs["output_"+aws.StringValue(o.OutputKey)] = aws.StringValue(o.OutputValue)
+	}
+	return outputs
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindFirstFailedCloudFormationEvent(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		events []*cloudformation.StackEvent
+		want   string
+	}{
+		{
+			name: "test rollback after resource failure",
+			events: []*cloudformation.StackEvent{
+				{
+					LogicalResourceId: aws.String("MyStack"),
+					ResourceType:      aws.String("AWS::CloudFormation::Stack"),
+					ResourceStatus:    aws.String("UPDATE_ROLLBACK_COMPLETE"),
+				},
+				{
+					LogicalResourceId:    aws.String("MyQueue"),
+					ResourceType:         aws.String("AWS::SQS::Queue"),
+					ResourceStatus:       aws.String("UPDATE_FAILED"),
+					ResourceStatusReason: aws.String("Resource update cancelled"),
+				},
+				{
+					LogicalResourceId:    aws.String("MyBucket"),
+					ResourceType:         aws.String("AWS::S3::Bucket"),
+					ResourceStatus:       aws.String("UPDATE_FAILED"),
+					ResourceStatusReason: aws.String("my-bucket already exists"),
+				},
+				{
+					LogicalResourceId: aws.String("MyStack"),
+					ResourceType:      aws.String("AWS::CloudFormation::Stack"),
+					ResourceStatus:    aws.String("UPDATE_IN_PROGRESS"),
+				},
+			},
+			want: "MyBucket",
+		},
+		{
+			name: "test events without failures",
+			events: []*cloudformation.StackEvent{
+				{
+					LogicalResourceId: aws.String("MyStack"),
+					ResourceType:      aws.String("AWS::CloudFormation::Stack"),
+					ResourceStatus:    aws.String("DELETE_COMPLETE"),
+				},
+			},
+			want: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			if event := findFirstFailedCloudFormationEvent(tc.events); event != nil {
+				got = aws.StringValue(event.LogicalResourceId)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestGetCloudFormationChangeSetType(t *testing.T) {
+	var testcases = []struct {
+		name      string
+		stack     *cloudformation.Stack
+		want      string
+		shouldErr bool
+		err       error
+	}{
+		{
+			name: "test stack not found",
+			want: "CREATE",
+		},
+		{
+			name: "test stack in review",
+			stack: &cloudformation.Stack{
+				StackStatus: aws.String("REVIEW_IN_PROGRESS"),
+			},
+			want: "CREATE",
+		},
+		{
+			name: "test stack updated",
+			stack: &cloudformation.Stack{
+				StackStatus: aws.String("UPDATE_ROLLBACK_COMPLETE"),
+			},
+			want: "UPDATE",
+		},
+		{
+			name: "test stack creation rolled back",
+			stack: &cloudformation.Stack{
+				StackStatus: aws.String("ROLLBACK_COMPLETE"),
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("aws cloudformation stack foo is ROLLBACK_COMPLETE and must be deleted before it can be deployed"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := getCloudFormationChangeSetType("foo", tc.stack)
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatalf("test name: %s, expected error, but got success", tc.name)
+				}
+				if diff := cmp.Diff(tc.err.Error(), err.Error()); diff != "" {
+					t.Fatalf("test name: %s, unexpected error (-want +got):\n%s", tc.name, diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
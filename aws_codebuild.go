@@ -0,0 +1,278 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfCodeBuildProjectExists checks whether a particular AWS CodeBuild project exists.
+func (ex *ExecutorPlugin) CheckIfCodeBuildProjectExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cb := codebuild.New(sess)
+
+	params := &codebuild.BatchGetProjectsInput{
+		Names: aws.StringSlice([]string{req.ProjectName}),
+	}
+
+	output, err := cb.BatchGetProjects(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws codebuild project: %s", err),
+			Status:         2,
+		}
+	}
+
+	if len(output.Projects) == 0 {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("aws codebuild project %q not found", req.ProjectName),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws codebuild project check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartCodeBuildExecution starts AWS CodeBuild build.
+func (ex *ExecutorPlugin) StartCodeBuildExecution(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cb := codebuild.New(sess)
+
+	params := &codebuild.StartBuildInput{
+		ProjectName: aws.String(req.ProjectName),
+	}
+	if req.SourceVersion != "" {
+		params.SourceVersion = aws.String(req.SourceVersion)
+	}
+	if req.BuildspecOverride != "" {
+		params.BuildspecOverride = aws.String(req.BuildspecOverride)
+	}
+	if len(req.EnvironmentVars) > 0 {
+		keys := make([]string, 0, len(req.EnvironmentVars))
+		for k := range req.EnvironmentVars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			params.EnvironmentVariablesOverride = append(params.EnvironmentVariablesOverride, &codebuild.EnvironmentVariable{
+				Name:  aws.String(k),
+				Value: aws.String(req.EnvironmentVars[k]),
+				Type:  aws.String(codebuild.EnvironmentVariableTypePlaintext),
+			})
+		}
+	}
+
+	output, err := cb.StartBuild(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to start aws codebuild build: %s", err),
+			Status:         2,
+		}
+	}
+
+	if output.Build == nil || aws.StringValue(output.Build.Id) == "" {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("aws codebuild start response has no build id"),
+			Status:         2,
+		}
+	}
+
+	buildID := aws.StringValue(output.Build.Id)
+
+	ex.Logger.Info("started aws codebuild build",
+		zap.String("plugin_name", app.Name),
+		zap.String("build_id", buildID),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: buildID,
+	})
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("started aws codebuild build %s", buildID),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 30 * time.Second,
+		},
+		Status: 3,
+		Outputs: map[string]string{
+			"build_id": buildID,
+		},
+	}
+}
+
+// CheckCodeBuildExecution checks the status of AWS CodeBuild build.
+func (ex *ExecutorPlugin) CheckCodeBuildExecution(req *PluginRequest, buildID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cb := codebuild.New(sess)
+
+	params := &codebuild.BatchGetBuildsInput{
+		Ids: aws.StringSlice([]string{buildID}),
+	}
+
+	output, err := cb.BatchGetBuilds(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to get aws codebuild build: %s", err),
+			Status:         2,
+		}
+	}
+
+	if len(output.Builds) == 0 {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("aws codebuild build %q not found", buildID),
+			Status:         2,
+		}
+	}
+
+	build := output.Builds[0]
+
+	ex.Logger.Info("checking aws codebuild build",
+		zap.String("plugin_name", app.Name),
+		zap.String("build_id", buildID),
+		zap.String("build_status", aws.StringValue(build.BuildStatus)),
+		zap.String("build_phase", aws.StringValue(build.CurrentPhase)),
+	)
+
+	return buildCodeBuildResponse(buildID, build)
+}
+
+// buildCodeBuildResponse returns the response for the status of AWS
+// CodeBuild build.
+func buildCodeBuildResponse(buildID string, build *codebuild.Build) *PluginResponse {
+	status := aws.StringValue(build.BuildStatus)
+
+	outputs := map[string]string{
+		"build_id":     buildID,
+		"build_number": strconv.FormatInt(aws.Int64Value(build.BuildNumber), 10),
+	}
+	if build.Logs != nil && build.Logs.DeepLink != nil {
+		outputs["build_log_url"] = aws.StringValue(build.Logs.DeepLink)
+	}
+
+	phases := summarizeCodeBuildPhases(build)
+
+	// SUCCEEDED | FAILED | FAULT | TIMED_OUT | IN_PROGRESS | STOPPED
+
+	switch status {
+	case codebuild.StatusTypeSucceeded:
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws codebuild build %s succeeded: %s", buildID, phases),
+			Status:  1,
+			Outputs: outputs,
+		}
+	case codebuild.StatusTypeFailed, codebuild.StatusTypeFault, codebuild.StatusTypeTimedOut, codebuild.StatusTypeStopped:
+		msg := fmt.Sprintf("aws codebuild build %s is %s", buildID, status)
+		if phase, reason := findFailedCodeBuildPhase(build); phase != "" {
+			msg += fmt.Sprintf(" in %s phase", phase)
+			if reason != "" {
+				msg += ": " + reason
+			}
+		}
+		resp := &PluginResponse{
+			Message: msg,
+			Status:  4,
+			Outputs: outputs,
+		}
+		if status == codebuild.StatusTypeTimedOut {
+			resp.FailureCategory = FailureTimeout
+		}
+		return resp
+	default:
+		// Covers In Progress
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws codebuild build %s is %s: %s", buildID, status, phases),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 30 * time.Second,
+			},
+			Status:  3,
+			Outputs: outputs,
+		}
+	}
+}
+
+// summarizeCodeBuildPhases returns the list of build phases and their statuses.
+func summarizeCodeBuildPhases(build *codebuild.Build) string {
+	var phases []string
+	for _, phase := range build.Phases {
+		status := aws.StringValue(phase.PhaseStatus)
+		if status == "" {
+			status = "IN_PROGRESS"
+		}
+		phases = append(phases, fmt.Sprintf("%s:%s", aws.StringValue(phase.PhaseType), status))
+	}
+	return strings.Join(phases, ", ")
+}
+
+// findFailedCodeBuildPhase returns the name of the first failed build phase
+// and the context messages associated with it.
+func findFailedCodeBuildPhase(build *codebuild.Build) (string, string) {
+	for _, phase := range build.Phases {
+		switch aws.StringValue(phase.PhaseStatus) {
+		case codebuild.StatusTypeFailed, codebuild.StatusTypeFault, codebuild.StatusTypeTimedOut, codebuild.StatusTypeStopped:
+			var messages []string
+			for _, c := range phase.Contexts {
+				if m := aws.StringValue(c.Message); m != "" {
+					messages = append(messages, m)
+				}
+			}
+			return aws.StringValue(phase.PhaseType), strings.Join(messages, "; ")
+		}
+	}
+	return "", ""
+}
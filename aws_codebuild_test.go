@@ -0,0 +1,148 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codebuild"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildCodeBuildResponse(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		build *codebuild.Build
+		want  *PluginResponse
+	}{
+		{
+			name: "test succeeded build",
+			build: &codebuild.Build{
+				BuildStatus: aws.String("SUCCEEDED"),
+				BuildNumber: aws.Int64(7),
+				Logs: &codebuild.LogsLocation{
+					DeepLink: aws.String("https://console.aws.amazon.com/cloudwatch/logs"),
+				},
+				Phases: []*codebuild.BuildPhase{
+					{PhaseType: aws.String("BUILD"), PhaseStatus: aws.String("SUCCEEDED")},
+					{PhaseType: aws.String("COMPLETED")},
+				},
+			},
+			want: &PluginResponse{
+				Message: "aws codebuild build foo:1 succeeded: BUILD:SUCCEEDED, COMPLETED:IN_PROGRESS",
+				Status:  1,
+				Outputs: map[string]string{
+					"build_id":      "foo:1",
+					"build_number":  "7",
+					"build_log_url": "https://console.aws.amazon.com/cloudwatch/logs",
+				},
+			},
+		},
+		{
+			name: "test failed build",
+			build: &codebuild.Build{
+				BuildStatus: aws.String("FAILED"),
+				BuildNumber: aws.Int64(7),
+				Phases: []*codebuild.BuildPhase{
+					{PhaseType: aws.String("INSTALL"), PhaseStatus: aws.String("SUCCEEDED")},
+					{
+						PhaseType:   aws.String("BUILD"),
+						PhaseStatus: aws.String("FAILED"),
+						Contexts: []*codebuild.PhaseContext{
+							{StatusCode: aws.String("COMMAND_EXECUTION_ERROR"), Message: aws.String("Error while executing command: make. Reason: exit status 2")},
+						},
+					},
+				},
+			},
+			want: &PluginResponse{
+				Message: "aws codebuild build foo:1 is FAILED in BUILD phase: Error while executing command: make. Reason: exit status 2",
+				Status:  4,
+				Outputs: map[string]string{
+					"build_id":     "foo:1",
+					"build_number": "7",
+				},
+			},
+		},
+		{
+			name: "test timed out build",
+			build: &codebuild.Build{
+				BuildStatus: aws.String("TIMED_OUT"),
+				BuildNumber: aws.Int64(7),
+				Phases: []*codebuild.BuildPhase{
+					{PhaseType: aws.String("BUILD"), PhaseStatus: aws.String("TIMED_OUT")},
+				},
+			},
+			want: &PluginResponse{
+				Message: "aws codebuild build foo:1 is TIMED_OUT in BUILD phase",
+				Status:  4,
+				Outputs: map[string]string{
+					"build_id":     "foo:1",
+					"build_number": "7",
+				},
+				FailureCategory: FailureTimeout,
+			},
+		},
+		{
+			name: "test stopped build without phases",
+			build: &codebuild.Build{
+				BuildStatus: aws.String("STOPPED"),
+				BuildNumber: aws.Int64(7),
+			},
+			want: &PluginResponse{
+				Message: "aws codebuild build foo:1 is STOPPED",
+				Status:  4,
+				Outputs: map[string]string{
+					"build_id":     "foo:1",
+					"build_number": "7",
+				},
+			},
+		},
+		{
+			name: "test build in progress",
+			build: &codebuild.Build{
+				BuildStatus: aws.String("IN_PROGRESS"),
+				BuildNumber: aws.Int64(7),
+				Phases: []*codebuild.BuildPhase{
+					{PhaseType: aws.String("SUBMITTED"), PhaseStatus: aws.String("SUCCEEDED")},
+					{PhaseType: aws.String("PROVISIONING")},
+				},
+			},
+			want: &PluginResponse{
+				Message:       "aws codebuild build foo:1 is IN_PROGRESS: SUBMITTED:SUCCEEDED, PROVISIONING:IN_PROGRESS",
+				ShouldRequeue: true,
+				RequeueDuration: &metav1.Duration{
+					Duration: 30 * time.Second,
+				},
+				Status: 3,
+				Outputs: map[string]string{
+					"build_id":     "foo:1",
+					"build_number": "7",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildCodeBuildResponse("foo:1", tc.build)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfGlueCrawlerExists checks whether a particular AWS Glue crawler exists.
+func (ex *ExecutorPlugin) CheckIfGlueCrawlerExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	g := glue.New(sess)
+
+	params := &glue.GetCrawlerInput{
+		Name: aws.String(req.CrawlerName),
+	}
+
+	output, err := g.GetCrawler(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws glue crawler: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws glue crawler check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartGlueCrawlerExecution starts AWS Glue crawler.
+func (ex *ExecutorPlugin) StartGlueCrawlerExecution(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	g := glue.New(sess)
+
+	params := &glue.StartCrawlerInput{
+		Name: aws.String(req.CrawlerName),
+	}
+
+	startedAt := time.Now().UTC()
+
+	if _, err := g.StartCrawler(params); err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to start aws glue crawler: %s", err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("started aws glue crawler",
+		zap.String("plugin_name", app.Name),
+		zap.String("crawler_name", req.CrawlerName),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID:        req.CrawlerName,
+		StartedAt: startedAt,
+	})
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("started aws glue crawler %s", req.CrawlerName),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 60 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// CheckGlueCrawlerExecution checks the status of AWS Glue crawler run.
+func (ex *ExecutorPlugin) CheckGlueCrawlerExecution(req *PluginRequest, wf *PluginWorkflow) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	g := glue.New(sess)
+
+	params := &glue.GetCrawlerInput{
+		Name: aws.String(wf.ID),
+	}
+
+	output, err := g.GetCrawler(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to get aws glue crawler: %s", err),
+			Status:         2,
+		}
+	}
+
+	crawler := output.Crawler
+	state := aws.StringValue(crawler.State)
+
+	var lastCrawlStatus string
+	if crawler.LastCrawl != nil {
+		lastCrawlStatus = aws.StringValue(crawler.LastCrawl.Status)
+	}
+
+	ex.Logger.Info("checking aws glue crawler",
+		zap.String("plugin_name", app.Name),
+		zap.String("crawler_name", wf.ID),
+		zap.String("crawler_state", state),
+		zap.String("last_crawl_status", lastCrawlStatus),
+	)
+
+	if !isGlueCrawlerRunFinished(crawler, wf.StartedAt) {
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws glue crawler %s is %s", wf.ID, state),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 60 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+
+	b, err := json.Marshal(crawler.LastCrawl)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws glue crawler execution response: %s", err),
+			Status:         2,
+		}
+	}
+
+	// SUCCEEDED, CANCELLED and FAILED
+
+	switch lastCrawlStatus {
+	case glue.LastCrawlStatusSucceeded:
+		return &PluginResponse{
+			Message: string(b),
+			Status:  1,
+		}
+	default:
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws glue crawler %s is %s: %s", wf.ID, lastCrawlStatus, aws.StringValue(crawler.LastCrawl.ErrorMessage)),
+			Status:  4,
+		}
+	}
+}
+
+// isGlueCrawlerRunFinished checks whether AWS Glue crawler finished the run
+// started at the time. The crawler goes from READY to RUNNING to STOPPING
+// and back to READY. The last crawl describes the outcome of the run only
+// when it started after the plugin started the crawler.
+func isGlueCrawlerRunFinished(crawler *glue.Crawler, startedAt time.Time) bool {
+	if aws.StringValue(crawler.State) != glue.CrawlerStateReady || crawler.LastCrawl == nil {
+		return false
+	}
+	return !aws.TimeValue(crawler.LastCrawl.StartTime).Before(startedAt.Add(-1 * time.Minute))
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsGlueCrawlerRunFinished(t *testing.T) {
+	startedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var testcases = []struct {
+		name    string
+		crawler *glue.Crawler
+		want    bool
+	}{
+		{
+			name: "test crawler running",
+			crawler: &glue.Crawler{
+				State: aws.String("RUNNING"),
+				LastCrawl: &glue.LastCrawlInfo{
+					Status:    aws.String("SUCCEEDED"),
+					StartTime: aws.Time(startedAt.Add(-24 * time.Hour)),
+				},
+			},
+			want: false,
+		},
+		{
+			name: "test crawler stopping",
+			crawler: &glue.Crawler{
+				State: aws.String("STOPPING"),
+			},
+			want: false,
+		},
+		{
+			name: "test crawler ready without last crawl",
+			crawler: &glue.Crawler{
+				State: aws.String("READY"),
+			},
+			want: false,
+		},
+		{
+			name: "test crawler ready with last crawl of previous run",
+			crawler: &glue.Crawler{
+				State: aws.String("READY"),
+				LastCrawl: &glue.LastCrawlInfo{
+					Status:    aws.String("SUCCEEDED"),
+					StartTime: aws.Time(startedAt.Add(-24 * time.Hour)),
+				},
+			},
+			want: false,
+		},
+		{
+			name: "test crawler ready with last crawl of the run",
+			crawler: &glue.Crawler{
+				State: aws.String("READY"),
+				LastCrawl: &glue.LastCrawlInfo{
+					Status:    aws.String("FAILED"),
+					StartTime: aws.Time(startedAt.Add(5 * time.Second)),
+				},
+			},
+			want: true,
+		},
+		{
+			name: "test crawler ready with last crawl started before the plugin recorded start",
+			crawler: &glue.Crawler{
+				State: aws.String("READY"),
+				LastCrawl: &glue.LastCrawlInfo{
+					Status:    aws.String("SUCCEEDED"),
+					StartTime: aws.Time(startedAt.Add(-30 * time.Second)),
+				},
+			},
+			want: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := isGlueCrawlerRunFinished(tc.crawler, startedAt)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
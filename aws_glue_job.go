@@ -17,10 +17,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/glue"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,9 +28,7 @@ import (
 
 // CheckIfGlueJobExists checks whether a particular AWS Glue job instance exists.
 func (ex *ExecutorPlugin) CheckIfGlueJobExists(req *PluginRequest) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -68,9 +66,7 @@ func (ex *ExecutorPlugin) CheckIfGlueJobExists(req *PluginRequest) *PluginRespon
 
 // StartGlueJobExecution starts AWS Glue job run.
 func (ex *ExecutorPlugin) StartGlueJobExecution(req *PluginRequest, workflowID string) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -79,9 +75,51 @@ func (ex *ExecutorPlugin) StartGlueJobExecution(req *PluginRequest, workflowID s
 
 	g := glue.New(sess)
 
+	if req.ResetJobBookmark {
+		if _, err := g.ResetJobBookmark(&glue.ResetJobBookmarkInput{
+			JobName: aws.String(req.JobName),
+		}); err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to reset aws glue job bookmark: %s", err),
+				Status:         2,
+			}
+		}
+		ex.Logger.Info("reset aws glue job bookmark",
+			zap.String("plugin_name", app.Name),
+			zap.String("job_name", req.JobName),
+		)
+	}
+
 	params := &glue.StartJobRunInput{
 		JobName: &req.JobName,
 	}
+	if req.WorkerType != "" {
+		params.WorkerType = aws.String(req.WorkerType)
+		params.NumberOfWorkers = aws.Int64(req.NumberOfWorkers)
+	}
+	if req.JobTimeout > 0 {
+		params.Timeout = aws.Int64(req.JobTimeout)
+	}
+	if req.ExecutionClass != "" {
+		params.ExecutionClass = aws.String(req.ExecutionClass)
+	}
+	if req.SecurityConfig != "" {
+		params.SecurityConfiguration = aws.String(req.SecurityConfig)
+	}
+	if req.NotifyDelayAfter > 0 {
+		params.NotificationProperty = &glue.NotificationProperty{
+			NotifyDelayAfter: aws.Int64(req.NotifyDelayAfter),
+		}
+	}
+	if req.JobBookmarkOption != "" {
+		params.Arguments = map[string]*string{
+			"--job-bookmark-option": aws.String("job-bookmark-" + req.JobBookmarkOption),
+		}
+		if req.JobBookmarkFrom != "" {
+			params.Arguments["--job-bookmark-from"] = aws.String(req.JobBookmarkFrom)
+			params.Arguments["--job-bookmark-to"] = aws.String(req.JobBookmarkTo)
+		}
+	}
 
 	output, err := g.StartJobRun(params)
 	if err != nil {
@@ -112,9 +150,9 @@ func (ex *ExecutorPlugin) StartGlueJobExecution(req *PluginRequest, workflowID s
 		zap.String("job_run_id", jobRunID),
 	)
 
-	ex.Workflows[workflowID] = &PluginWorkflow{
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
 		ID: jobRunID,
-	}
+	})
 
 	return &PluginResponse{
 		Message:       string(b),
@@ -128,9 +166,7 @@ func (ex *ExecutorPlugin) StartGlueJobExecution(req *PluginRequest, workflowID s
 
 // CheckGlueJobExecution checks the status of AWS Glue job run.
 func (ex *ExecutorPlugin) CheckGlueJobExecution(req *PluginRequest, jobRunID string) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -176,10 +212,43 @@ func (ex *ExecutorPlugin) CheckGlueJobExecution(req *PluginRequest, jobRunID str
 			Status:  1,
 		}
 	case "STOPPED", "FAILED", "ERROR", "TIMEOUT":
-		return &PluginResponse{
-			Message: string(b),
-			Status:  2,
+		errorMessage := aws.StringValue(output.JobRun.ErrorMessage)
+		msg := fmt.Sprintf("aws glue job run %s is %s", jobRunID, aws.StringValue(output.JobRun.JobRunState))
+		if errorMessage != "" {
+			msg += ": " + errorMessage
+		}
+		outputs := map[string]string{
+			"job_run_id":    jobRunID,
+			"error_message": errorMessage,
+		}
+
+		// The error output of the job run is written to the error log
+		// stream named after the job run.
+		logGroupName := aws.StringValue(output.JobRun.LogGroupName)
+		if logGroupName == "" {
+			logGroupName = "/aws-glue/jobs"
+		}
+		lines, err := tailCloudWatchLogStream(sess, logGroupName+"/error", jobRunID, getLogTailLines(req))
+		if err != nil {
+			ex.Logger.Warn("failed to fetch aws glue job run error log",
+				zap.String("plugin_name", app.Name),
+				zap.String("job_run_id", jobRunID),
+				zap.Error(err),
+			)
+		} else if len(lines) > 0 {
+			outputs["error_log_tail"] = strings.Join(lines, "\n")
+			msg += fmt.Sprintf("\nlast %d lines of error log:\n%s", len(lines), outputs["error_log_tail"])
+		}
+
+		resp := &PluginResponse{
+			Message: msg,
+			Status:  4,
+			Outputs: outputs,
+		}
+		if aws.StringValue(output.JobRun.JobRunState) == "TIMEOUT" {
+			resp.FailureCategory = FailureTimeout
 		}
+		return resp
 	default:
 		// Covers Stopping and Executing
 		return &PluginResponse{
@@ -0,0 +1,284 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckIfGlueWorkflowExists checks whether a particular AWS Glue workflow exists.
+func (ex *ExecutorPlugin) CheckIfGlueWorkflowExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	g := glue.New(sess)
+
+	params := &glue.GetWorkflowInput{
+		Name: aws.String(req.GlueWorkflowName),
+	}
+
+	output, err := g.GetWorkflow(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws glue workflow: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws glue workflow check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartGlueWorkflowExecution starts AWS Glue workflow run.
+func (ex *ExecutorPlugin) StartGlueWorkflowExecution(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	g := glue.New(sess)
+
+	params := &glue.StartWorkflowRunInput{
+		Name: aws.String(req.GlueWorkflowName),
+	}
+
+	output, err := g.StartWorkflowRun(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to start aws glue workflow: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws glue workflow start response: %s", err),
+			Status:         2,
+		}
+	}
+
+	runID := aws.StringValue(output.RunId)
+	if runID == "" {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("aws glue workflow start response has no run id"),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("started aws glue workflow run",
+		zap.String("plugin_name", app.Name),
+		zap.String("workflow_name", req.GlueWorkflowName),
+		zap.String("run_id", runID),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: runID,
+	})
+
+	return &PluginResponse{
+		Message:       string(b),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 60 * time.Second,
+		},
+		Status: 3,
+		Outputs: map[string]string{
+			"run_id": runID,
+		},
+	}
+}
+
+// CheckGlueWorkflowExecution checks the status of AWS Glue workflow run.
+func (ex *ExecutorPlugin) CheckGlueWorkflowExecution(req *PluginRequest, runID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	g := glue.New(sess)
+
+	params := &glue.GetWorkflowRunInput{
+		Name:         aws.String(req.GlueWorkflowName),
+		RunId:        aws.String(runID),
+		IncludeGraph: aws.Bool(true),
+	}
+
+	output, err := g.GetWorkflowRun(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to get aws glue workflow run: %s", err),
+			Status:         2,
+		}
+	}
+
+	run := output.Run
+	status := aws.StringValue(run.Status)
+
+	ex.Logger.Info("checking aws glue workflow run",
+		zap.String("plugin_name", app.Name),
+		zap.String("workflow_name", req.GlueWorkflowName),
+		zap.String("run_id", runID),
+		zap.String("run_status", status),
+	)
+
+	summary := summarizeGlueWorkflowRun(run)
+	progress := getGlueWorkflowRunProgress(run)
+	outputs := map[string]string{
+		"run_id": runID,
+	}
+	if run.Statistics != nil {
+		if b, err := json.Marshal(run.Statistics); err == nil {
+			outputs["statistics"] = string(b)
+		}
+	}
+
+	// RUNNING, COMPLETED, STOPPING, STOPPED and ERROR
+
+	switch status {
+	case glue.WorkflowRunStatusCompleted:
+		if failedNodes := listFailedGlueWorkflowNodes(run); len(failedNodes) > 0 {
+			return &PluginResponse{
+				Message: fmt.Sprintf("aws glue workflow run %s completed with failures: %s; failed nodes: %s",
+					runID, summary, strings.Join(failedNodes, ", ")),
+				Status:   4,
+				Outputs:  outputs,
+				Progress: progress,
+			}
+		}
+		return &PluginResponse{
+			Message:  fmt.Sprintf("aws glue workflow run %s completed: %s", runID, summary),
+			Status:   1,
+			Outputs:  outputs,
+			Progress: progress,
+		}
+	case glue.WorkflowRunStatusStopped, glue.WorkflowRunStatusError:
+		msg := fmt.Sprintf("aws glue workflow run %s is %s: %s", runID, status, summary)
+		if run.ErrorMessage != nil {
+			msg += "; " + aws.StringValue(run.ErrorMessage)
+		}
+		return &PluginResponse{
+			Message:  msg,
+			Status:   4,
+			Outputs:  outputs,
+			Progress: progress,
+		}
+	default:
+		// Covers Running and Stopping
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws glue workflow run %s is %s: %s", runID, status, summary),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 60 * time.Second,
+			},
+			Status:   3,
+			Outputs:  outputs,
+			Progress: progress,
+		}
+	}
+}
+
+// summarizeGlueWorkflowRun returns the summary of the action statistics of a workflow run.
+func summarizeGlueWorkflowRun(run *glue.WorkflowRun) string {
+	st := run.Statistics
+	if st == nil {
+		return "no statistics"
+	}
+	return fmt.Sprintf("%d/%d succeeded, %d running, %d waiting, %d failed, %d errored, %d timed out, %d stopped",
+		aws.Int64Value(st.SucceededActions), aws.Int64Value(st.TotalActions),
+		aws.Int64Value(st.RunningActions), aws.Int64Value(st.WaitingActions),
+		aws.Int64Value(st.FailedActions), aws.Int64Value(st.ErroredActions),
+		aws.Int64Value(st.TimeoutActions), aws.Int64Value(st.StoppedActions),
+	)
+}
+
+// getGlueWorkflowRunProgress returns the number of finished actions out of
+// all actions of a workflow run.
+func getGlueWorkflowRunProgress(run *glue.WorkflowRun) wfv1.Progress {
+	st := run.Statistics
+	if st == nil {
+		return wfv1.ProgressUndefined
+	}
+	finished := aws.Int64Value(st.SucceededActions) + aws.Int64Value(st.FailedActions) +
+		aws.Int64Value(st.ErroredActions) + aws.Int64Value(st.TimeoutActions) +
+		aws.Int64Value(st.StoppedActions)
+	return newProgress(finished, aws.Int64Value(st.TotalActions))
+}
+
+// listFailedGlueWorkflowNodes returns the names of the job and crawler nodes
+// of a workflow run that failed and have no successful run, e.g. a retry.
+func listFailedGlueWorkflowNodes(run *glue.WorkflowRun) []string {
+	var nodes []string
+	if run.Graph == nil {
+		return nodes
+	}
+	for _, node := range run.Graph.Nodes {
+		var states []string
+		if node.JobDetails != nil {
+			for _, jobRun := range node.JobDetails.JobRuns {
+				states = append(states, aws.StringValue(jobRun.JobRunState))
+			}
+		}
+		if node.CrawlerDetails != nil {
+			for _, crawl := range node.CrawlerDetails.Crawls {
+				states = append(states, aws.StringValue(crawl.State))
+			}
+		}
+		var succeeded bool
+		var failedState string
+		for _, state := range states {
+			switch state {
+			case "SUCCEEDED":
+				succeeded = true
+			case "FAILED", "ERROR", "TIMEOUT", "STOPPED", "CANCELLED":
+				failedState = state
+			}
+		}
+		if !succeeded && failedState != "" {
+			nodes = append(nodes, fmt.Sprintf("%s (%s)", aws.StringValue(node.Name), failedState))
+		}
+	}
+	return nodes
+}
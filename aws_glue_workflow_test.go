@@ -0,0 +1,114 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListFailedGlueWorkflowNodes(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		input *glue.WorkflowRun
+		want  []string
+	}{
+		{
+			name:  "test workflow run without graph",
+			input: &glue.WorkflowRun{},
+		},
+		{
+			name: "test workflow run with failed job and retried crawler",
+			input: &glue.WorkflowRun{
+				Graph: &glue.WorkflowGraph{
+					Nodes: []*glue.Node{
+						{
+							Name: aws.String("trigger"),
+							Type: aws.String("TRIGGER"),
+						},
+						{
+							Name: aws.String("extract"),
+							Type: aws.String("JOB"),
+							JobDetails: &glue.JobNodeDetails{
+								JobRuns: []*glue.JobRun{
+									{JobRunState: aws.String("FAILED")},
+								},
+							},
+						},
+						{
+							Name: aws.String("catalog"),
+							Type: aws.String("CRAWLER"),
+							CrawlerDetails: &glue.CrawlerNodeDetails{
+								Crawls: []*glue.Crawl{
+									{State: aws.String("FAILED")},
+									{State: aws.String("SUCCEEDED")},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: []string{"extract (FAILED)"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := listFailedGlueWorkflowNodes(tc.input)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestGetGlueWorkflowRunProgress(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		input *glue.WorkflowRun
+		want  wfv1.Progress
+	}{
+		{
+			name:  "test workflow run without statistics",
+			input: &glue.WorkflowRun{},
+			want:  wfv1.ProgressUndefined,
+		},
+		{
+			name: "test running workflow run",
+			input: &glue.WorkflowRun{
+				Statistics: &glue.WorkflowRunStatistics{
+					TotalActions:     aws.Int64(5),
+					SucceededActions: aws.Int64(2),
+					FailedActions:    aws.Int64(1),
+					RunningActions:   aws.Int64(1),
+					WaitingActions:   aws.Int64(1),
+				},
+			},
+			want: wfv1.Progress("3/5"),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getGlueWorkflowRunProgress(tc.input)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -17,10 +17,12 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,9 +30,7 @@ import (
 
 // CheckIfLambdaFunctionExists checks whether a particular AWS Lambda Function instance exists.
 func (ex *ExecutorPlugin) CheckIfLambdaFunctionExists(req *PluginRequest) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -78,9 +78,7 @@ func InvokeLambdaFunctionAsync(ex *ExecutorPlugin, req *PluginRequest, wf *Plugi
 		}
 	}()
 
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		wf.Lock()
 		wf.Status = "FAILED"
@@ -110,8 +108,8 @@ func InvokeLambdaFunctionAsync(ex *ExecutorPlugin, req *PluginRequest, wf *Plugi
 		Payload:        payload,
 	}
 
-	output, err := cli.Invoke(params)
-	if err != nil {
+	r, output := cli.InvokeRequest(params)
+	if err := r.Send(); err != nil {
 		wf.Lock()
 		wf.Status = "FAILED"
 		wf.Message = fmt.Sprintf("aws lambda invocation failed: %s", err)
@@ -121,9 +119,25 @@ func InvokeLambdaFunctionAsync(ex *ExecutorPlugin, req *PluginRequest, wf *Plugi
 
 	ex.Logger.Info("completed aws lambda invocation",
 		zap.String("plugin_name", app.Name),
+		zap.String("request_id", r.RequestID),
 		zap.Int64("status_code", *output.StatusCode),
 	)
 
+	if req.FetchLogs {
+		// The logs of the invocation are fetched by the checks of the
+		// execution once the function completes.
+		wf.Lock()
+		wf.ID = r.RequestID
+		wf.Status = "INVOKED"
+		wf.Message = fmt.Sprintf("waiting for aws lambda invocation %s logs", r.RequestID)
+		wf.StartedAt = time.Now().UTC()
+		wf.Outputs = map[string]string{
+			"request_id": r.RequestID,
+		}
+		wf.Unlock()
+		return
+	}
+
 	b, err := json.Marshal(output)
 	if err != nil {
 		wf.Lock()
@@ -146,7 +160,7 @@ func (ex *ExecutorPlugin) StartLambdaFunctionExecution(req *PluginRequest, workf
 		Status:  "RUNNING",
 		Message: "running aws lambda function async execution",
 	}
-	ex.Workflows[workflowID] = wf
+	ex.setWorkflow(req, workflowID, wf)
 
 	go InvokeLambdaFunctionAsync(ex, req, wf)
 
@@ -179,6 +193,8 @@ func (ex *ExecutorPlugin) CheckLambdaFunctionExecution(req *PluginRequest, wf *P
 			Message: wf.Message,
 			Status:  1,
 		}
+	case "INVOKED":
+		return ex.checkLambdaInvocationLogs(req, wf)
 	case "FAILED":
 		return &PluginResponse{
 			Message: wf.Message,
@@ -196,3 +212,154 @@ func (ex *ExecutorPlugin) CheckLambdaFunctionExecution(req *PluginRequest, wf *P
 		}
 	}
 }
+
+// lambdaLogDeliveryDelay is the time allowed for the delivery of the logs of
+// AWS Lambda invocation after the function times out.
+const lambdaLogDeliveryDelay = 2 * time.Minute
+
+// checkLambdaInvocationLogs fetches the logs of AWS Lambda invocation. The
+// node is requeued until the invocation completes, and succeeds without the
+// logs when they are not delivered in time.
+func (ex *ExecutorPlugin) checkLambdaInvocationLogs(req *PluginRequest, wf *PluginWorkflow) *PluginResponse {
+	requestID := wf.ID
+	msg := fmt.Sprintf("aws lambda invocation %s completed", requestID)
+
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cfg, err := lambda.New(sess).GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(req.LambdaFunctionName),
+	})
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws lambda function configuration: %s", err),
+			Status:         2,
+		}
+	}
+
+	lines, completed, err := tailLambdaInvocationLogs(sess, getLambdaLogGroupName(cfg), requestID, wf.StartedAt, getLogTailLines(req))
+	if err != nil {
+		ex.Logger.Warn("failed to fetch aws lambda invocation logs",
+			zap.String("plugin_name", app.Name),
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		)
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws lambda invocation %s logs are not available", requestID),
+			Status:  1,
+			Outputs: wf.Outputs,
+		}
+	}
+
+	if !completed {
+		// The function runs for at most its timeout, and the logs are
+		// delivered with a delay.
+		deadline := wf.StartedAt.Add(time.Duration(aws.Int64Value(cfg.Timeout))*time.Second + lambdaLogDeliveryDelay)
+		if time.Now().Before(deadline) {
+			return &PluginResponse{
+				Message:       wf.Message,
+				ShouldRequeue: true,
+				RequeueDuration: &metav1.Duration{
+					Duration: 10 * time.Second,
+				},
+				Status: 3,
+			}
+		}
+		ex.Logger.Warn("aws lambda invocation logs not found",
+			zap.String("plugin_name", app.Name),
+			zap.String("request_id", requestID),
+		)
+		msg = fmt.Sprintf("aws lambda invocation %s logs are not available", requestID)
+	}
+
+	outputs := map[string]string{
+		"request_id": requestID,
+	}
+	if len(lines) > 0 {
+		outputs["log_tail"] = strings.Join(lines, "\n")
+		msg += fmt.Sprintf("\nlast %d lines of log:\n%s", len(lines), outputs["log_tail"])
+	}
+	return &PluginResponse{
+		Message: msg,
+		Status:  1,
+		Outputs: outputs,
+	}
+}
+
+// getLambdaLogGroupName returns the name of Amazon CloudWatch Logs log group
+// of AWS Lambda function. The functions without custom logging configuration
+// log to the default log group.
+func getLambdaLogGroupName(cfg *lambda.FunctionConfiguration) string {
+	if cfg.LoggingConfig != nil && aws.StringValue(cfg.LoggingConfig.LogGroup) != "" {
+		return aws.StringValue(cfg.LoggingConfig.LogGroup)
+	}
+	return "/aws/lambda/" + aws.StringValue(cfg.FunctionName)
+}
+
+// tailLambdaInvocationLogs returns the last lines of the logs of AWS Lambda
+// function invocation, and whether the invocation has completed. The log
+// stream is found by the START line of the invocation and read until its
+// REPORT line.
+func tailLambdaInvocationLogs(sess *session.Session, logGroupName, requestID string, startTime time.Time, limit int64) ([]string, bool, error) {
+	cli := cloudwatchlogs.New(sess)
+
+	// The pages of filtered events may be empty while the search continues,
+	// so the pages are read until the START line is found.
+	var start *cloudwatchlogs.FilteredLogEvent
+	if err := cli.FilterLogEventsPages(&cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(logGroupName),
+		FilterPattern: aws.String(fmt.Sprintf("%q", "START RequestId: "+requestID)),
+		StartTime:     aws.Int64(startTime.Add(-1 * time.Minute).UnixMilli()),
+	}, func(page *cloudwatchlogs.FilterLogEventsOutput, lastPage bool) bool {
+		if len(page.Events) > 0 {
+			start = page.Events[0]
+			return false
+		}
+		return true
+	}); err != nil {
+		return nil, false, fmt.Errorf("failed to filter amazon cloudwatch logs events: %s", err)
+	}
+	if start == nil {
+		return nil, false, nil
+	}
+
+	var lines []string
+	var completed bool
+	if err := cli.GetLogEventsPages(&cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroupName),
+		LogStreamName: start.LogStreamName,
+		StartTime:     start.Timestamp,
+		StartFromHead: aws.Bool(true),
+	}, func(page *cloudwatchlogs.GetLogEventsOutput, lastPage bool) bool {
+		lines, completed = appendLambdaInvocationLogLines(lines, page.Events, requestID, limit)
+		return !completed && len(page.Events) > 0
+	}); err != nil {
+		return nil, false, fmt.Errorf("failed to get amazon cloudwatch logs events: %s", err)
+	}
+	return lines, completed, nil
+}
+
+// appendLambdaInvocationLogLines appends the log events of AWS Lambda
+// invocation to the last lines of the log. It returns true once the REPORT
+// line of the invocation is found.
+func appendLambdaInvocationLogLines(lines []string, events []*cloudwatchlogs.OutputLogEvent, requestID string, limit int64) ([]string, bool) {
+	reportLine := "REPORT RequestId: " + requestID
+	var completed bool
+	for _, event := range events {
+		message := aws.StringValue(event.Message)
+		lines = append(lines, formatLogLine(message))
+		if strings.HasPrefix(message, reportLine) {
+			completed = true
+			break
+		}
+	}
+	if int64(len(lines)) > limit {
+		lines = lines[int64(len(lines))-limit:]
+	}
+	return lines, completed
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetLambdaLogGroupName(t *testing.T) {
+	var testcases = []struct {
+		name string
+		cfg  *lambda.FunctionConfiguration
+		want string
+	}{
+		{
+			name: "test default log group",
+			cfg: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("foo"),
+			},
+			want: "/aws/lambda/foo",
+		},
+		{
+			name: "test custom log group",
+			cfg: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("foo"),
+				LoggingConfig: &lambda.LoggingConfig{
+					LogFormat: aws.String("JSON"),
+					LogGroup:  aws.String("/shared/functions"),
+				},
+			},
+			want: "/shared/functions",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getLambdaLogGroupName(tc.cfg)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestAppendLambdaInvocationLogLines(t *testing.T) {
+	var testcases = []struct {
+		name          string
+		lines         []string
+		events        []string
+		limit         int64
+		want          []string
+		wantCompleted bool
+	}{
+		{
+			name:   "test invocation in progress",
+			events: []string{"START RequestId: abc Version: $LATEST\n", "processing\n"},
+			limit:  10,
+			want:   []string{"START RequestId: abc Version: $LATEST", "processing"},
+		},
+		{
+			name:          "test invocation completed",
+			lines:         []string{"START RequestId: abc Version: $LATEST"},
+			events:        []string{"processed\n", "END RequestId: abc\n", "REPORT RequestId: abc\tDuration: 1.00 ms\n", "START RequestId: def Version: $LATEST\n"},
+			limit:         3,
+			want:          []string{"processed", "END RequestId: abc", "REPORT RequestId: abc\tDuration: 1.00 ms"},
+			wantCompleted: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []*cloudwatchlogs.OutputLogEvent
+			for _, msg := range tc.events {
+				events = append(events, &cloudwatchlogs.OutputLogEvent{Message: aws.String(msg)})
+			}
+			lines, completed := appendLambdaInvocationLogLines(tc.lines, events, "abc", tc.limit)
+			got := map[string]interface{}{
+				"lines":     lines,
+				"completed": completed,
+			}
+			want := map[string]interface{}{
+				"lines":     tc.want,
+				"completed": tc.wantCompleted,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StartSSMAutomationExecution starts AWS Systems Manager Automation execution.
+func (ex *ExecutorPlugin) StartSSMAutomationExecution(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ssm.New(sess)
+
+	params := &ssm.StartAutomationExecutionInput{
+		DocumentName: aws.String(req.DocumentName),
+		Parameters:   buildSSMParameters(req.Parameters),
+	}
+	if req.MaxConcurrency != "" {
+		params.MaxConcurrency = aws.String(req.MaxConcurrency)
+	}
+	if req.MaxErrors != "" {
+		params.MaxErrors = aws.String(req.MaxErrors)
+	}
+
+	output, err := cli.StartAutomationExecution(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to start aws ssm automation execution: %s", err),
+			Status:         2,
+		}
+	}
+
+	executionID := aws.StringValue(output.AutomationExecutionId)
+
+	ex.Logger.Info("started aws ssm automation execution",
+		zap.String("plugin_name", app.Name),
+		zap.String("document_name", req.DocumentName),
+		zap.String("automation_execution_id", executionID),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: executionID,
+	})
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("started aws ssm automation execution %s", executionID),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 30 * time.Second,
+		},
+		Status: 3,
+		Outputs: map[string]string{
+			"automation_execution_id": executionID,
+		},
+	}
+}
+
+// CheckSSMAutomationExecution checks the status of AWS Systems Manager
+// Automation execution.
+func (ex *ExecutorPlugin) CheckSSMAutomationExecution(req *PluginRequest, executionID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ssm.New(sess)
+
+	params := &ssm.GetAutomationExecutionInput{
+		AutomationExecutionId: aws.String(executionID),
+	}
+
+	output, err := cli.GetAutomationExecution(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to get aws ssm automation execution: %s", err),
+			Status:         2,
+		}
+	}
+
+	execution := output.AutomationExecution
+	status := aws.StringValue(execution.AutomationExecutionStatus)
+
+	ex.Logger.Info("checking aws ssm automation execution",
+		zap.String("plugin_name", app.Name),
+		zap.String("automation_execution_id", executionID),
+		zap.String("automation_execution_status", status),
+		zap.String("current_step_name", aws.StringValue(execution.CurrentStepName)),
+	)
+
+	steps := summarizeSSMAutomationSteps(execution.StepExecutions)
+
+	outputs := map[string]string{
+		"automation_execution_id": executionID,
+	}
+	if len(execution.Outputs) > 0 {
+		b, err := json.Marshal(execution.Outputs)
+		if err != nil {
+			return &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to pack aws ssm automation execution outputs: %s", err),
+				Status:         2,
+			}
+		}
+		outputs["automation_outputs"] = string(b)
+	}
+
+	switch status {
+	case ssm.AutomationExecutionStatusSuccess, ssm.AutomationExecutionStatusCompletedWithSuccess:
+		return &PluginResponse{
+			Message: fmt.Sprintf("aws ssm automation execution %s is %s: %s", executionID, status, steps),
+			Status:  1,
+			Outputs: outputs,
+		}
+	case ssm.AutomationExecutionStatusFailed,
+		ssm.AutomationExecutionStatusTimedOut,
+		ssm.AutomationExecutionStatusCancelled,
+		ssm.AutomationExecutionStatusRejected,
+		ssm.AutomationExecutionStatusCompletedWithFailure,
+		ssm.AutomationExecutionStatusChangeCalendarOverrideRejected:
+		outputs["failure_message"] = aws.StringValue(execution.FailureMessage)
+		resp := &PluginResponse{
+			Message: fmt.Sprintf("aws ssm automation execution %s is %s: %s: %s",
+				executionID, status, steps, aws.StringValue(execution.FailureMessage)),
+			Status:  4,
+			Outputs: outputs,
+		}
+		if status == ssm.AutomationExecutionStatusTimedOut {
+			resp.FailureCategory = FailureTimeout
+		}
+		return resp
+	default:
+		// Covers Pending, In Progress, Waiting, etc.
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws ssm automation execution %s is %s: %s", executionID, status, steps),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 30 * time.Second,
+			},
+			Status:  3,
+			Outputs: outputs,
+		}
+	}
+}
+
+// summarizeSSMAutomationSteps returns the list of automation steps and
+// their statuses.
+func summarizeSSMAutomationSteps(steps []*ssm.StepExecution) string {
+	if len(steps) == 0 {
+		return "no steps"
+	}
+	var entries []string
+	for _, step := range steps {
+		entries = append(entries, fmt.Sprintf("%s:%s", aws.StringValue(step.StepName), aws.StringValue(step.StepStatus)))
+	}
+	return strings.Join(entries, ", ")
+}
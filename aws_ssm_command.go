@@ -0,0 +1,346 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ssmMaxOutputLength is the maximum length of the command output
+// reported for an instance.
+const ssmMaxOutputLength = 1024
+
+// ssmMaxReportedInstances is the maximum number of instances whose command
+// output is reported in the outputs.
+const ssmMaxReportedInstances = 10
+
+// CheckIfSSMDocumentExists checks whether a particular AWS Systems Manager document exists.
+func (ex *ExecutorPlugin) CheckIfSSMDocumentExists(req *PluginRequest) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ssm.New(sess)
+
+	params := &ssm.DescribeDocumentInput{
+		Name: aws.String(req.DocumentName),
+	}
+
+	output, err := cli.DescribeDocument(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws ssm document: %s", err),
+			Status:         2,
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws ssm document check response: %s", err),
+			Status:         2,
+		}
+	}
+
+	return &PluginResponse{
+		Message: string(b),
+		Status:  1,
+	}
+}
+
+// StartSSMCommandExecution runs AWS Systems Manager document against the
+// instances selected by their IDs or tags.
+func (ex *ExecutorPlugin) StartSSMCommandExecution(req *PluginRequest, workflowID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ssm.New(sess)
+
+	params := &ssm.SendCommandInput{
+		DocumentName: aws.String(req.DocumentName),
+		Comment:      aws.String(fmt.Sprintf("argo workflow %s", workflowID)),
+		Parameters:   buildSSMParameters(req.Parameters),
+	}
+	if len(req.InstanceIDs) > 0 {
+		params.InstanceIds = aws.StringSlice(req.InstanceIDs)
+	} else {
+		params.Targets = buildSSMTargets(req.TargetTags)
+	}
+	if req.MaxConcurrency != "" {
+		params.MaxConcurrency = aws.String(req.MaxConcurrency)
+	}
+	if req.MaxErrors != "" {
+		params.MaxErrors = aws.String(req.MaxErrors)
+	}
+
+	output, err := cli.SendCommand(params)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to send aws ssm command: %s", err),
+			Status:         2,
+		}
+	}
+
+	commandID := aws.StringValue(output.Command.CommandId)
+
+	ex.Logger.Info("sent aws ssm command",
+		zap.String("plugin_name", app.Name),
+		zap.String("document_name", req.DocumentName),
+		zap.String("command_id", commandID),
+	)
+
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
+		ID: commandID,
+	})
+
+	return &PluginResponse{
+		Message:       fmt.Sprintf("sent aws ssm command %s", commandID),
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 15 * time.Second,
+		},
+		Status: 3,
+		Outputs: map[string]string{
+			"command_id": commandID,
+		},
+	}
+}
+
+// CheckSSMCommandExecution checks the status of AWS Systems Manager command
+// and its invocations on each of the instances.
+func (ex *ExecutorPlugin) CheckSSMCommandExecution(req *PluginRequest, commandID string) *PluginResponse {
+	sess, err := newAWSSession(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
+			Status:         2,
+		}
+	}
+
+	cli := ssm.New(sess)
+
+	commands, err := cli.ListCommands(&ssm.ListCommandsInput{
+		CommandId: aws.String(commandID),
+	})
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to get aws ssm command: %s", err),
+			Status:         2,
+		}
+	}
+	if len(commands.Commands) == 0 {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("aws ssm command %q not found", commandID),
+			Status:         2,
+		}
+	}
+
+	status := aws.StringValue(commands.Commands[0].Status)
+
+	var invocations []*ssm.CommandInvocation
+	if err := cli.ListCommandInvocationsPages(&ssm.ListCommandInvocationsInput{
+		CommandId: aws.String(commandID),
+	}, func(page *ssm.ListCommandInvocationsOutput, lastPage bool) bool {
+		invocations = append(invocations, page.CommandInvocations...)
+		return true
+	}); err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to list aws ssm command invocations: %s", err),
+			Status:         2,
+		}
+	}
+
+	ex.Logger.Info("checking aws ssm command",
+		zap.String("plugin_name", app.Name),
+		zap.String("command_id", commandID),
+		zap.String("command_status", status),
+		zap.Int("invocation_count", len(invocations)),
+	)
+
+	instanceStatuses := make(map[string]string)
+	for _, inv := range invocations {
+		instanceStatuses[aws.StringValue(inv.InstanceId)] = aws.StringValue(inv.Status)
+	}
+	summary := summarizeSSMInvocations(instanceStatuses)
+
+	switch status {
+	case ssm.CommandStatusSuccess, ssm.CommandStatusFailed, ssm.CommandStatusCancelled, ssm.CommandStatusTimedOut:
+	default:
+		// Covers Pending, InProgress and Cancelling
+		return &PluginResponse{
+			Message:       fmt.Sprintf("aws ssm command %s is %s: %s", commandID, status, summary),
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 15 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+
+	b, err := json.Marshal(instanceStatuses)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to pack aws ssm command invocation statuses: %s", err),
+			Status:         2,
+		}
+	}
+
+	outputs := map[string]string{
+		"command_id":        commandID,
+		"instance_statuses": string(b),
+	}
+
+	var details []string
+	for i, inv := range invocations {
+		if i >= ssmMaxReportedInstances {
+			break
+		}
+		instanceID := aws.StringValue(inv.InstanceId)
+		output, err := cli.GetCommandInvocation(&ssm.GetCommandInvocationInput{
+			CommandId:  aws.String(commandID),
+			InstanceId: aws.String(instanceID),
+		})
+		if err != nil {
+			ex.Logger.Warn("failed to get aws ssm command invocation",
+				zap.String("plugin_name", app.Name),
+				zap.String("command_id", commandID),
+				zap.String("instance_id", instanceID),
+				zap.Error(err),
+			)
+			continue
+		}
+		stdout := truncateSSMOutput(aws.StringValue(output.StandardOutputContent))
+		stderr := truncateSSMOutput(aws.StringValue(output.StandardErrorContent))
+		outputs["stdout_"+instanceID] = stdout
+		outputs["stderr_"+instanceID] = stderr
+		if aws.StringValue(output.Status) != ssm.CommandInvocationStatusSuccess && stderr != "" {
+			details = append(details, fmt.Sprintf("%s: %s", instanceID, stderr))
+		}
+	}
+
+	msg := fmt.Sprintf("aws ssm command %s is %s: %s", commandID, status, summary)
+	if len(details) > 0 {
+		msg += "; " + strings.Join(details, "; ")
+	}
+
+	if status == ssm.CommandStatusSuccess {
+		return &PluginResponse{
+			Message: msg,
+			Status:  1,
+			Outputs: outputs,
+		}
+	}
+
+	resp := &PluginResponse{
+		Message: msg,
+		Status:  4,
+		Outputs: outputs,
+	}
+	if status == ssm.CommandStatusTimedOut {
+		resp.FailureCategory = FailureTimeout
+	}
+	return resp
+}
+
+// buildSSMParameters converts the parameters of the request to the
+// parameters of AWS Systems Manager document. A list becomes multiple
+// values of the parameter.
+func buildSSMParameters(m map[string]interface{}) map[string][]*string {
+	if len(m) == 0 {
+		return nil
+	}
+	params := make(map[string][]*string)
+	for k, v := range m {
+		switch value := v.(type) {
+		case []interface{}:
+			for _, item := range value {
+				params[k] = append(params[k], aws.String(formatSSMParameterValue(item)))
+			}
+		default:
+			params[k] = []*string{aws.String(formatSSMParameterValue(value))}
+		}
+	}
+	return params
+}
+
+func formatSSMParameterValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// buildSSMTargets converts the tags to AWS Systems Manager targets.
+func buildSSMTargets(tags map[string]string) []*ssm.Target {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var targets []*ssm.Target
+	for _, k := range keys {
+		targets = append(targets, &ssm.Target{
+			Key:    aws.String("tag:" + k),
+			Values: aws.StringSlice([]string{tags[k]}),
+		})
+	}
+	return targets
+}
+
+// summarizeSSMInvocations returns the list of instances and the statuses
+// of the command invocations.
+func summarizeSSMInvocations(statuses map[string]string) string {
+	if len(statuses) == 0 {
+		return "no invocations"
+	}
+	keys := make([]string, 0, len(statuses))
+	for k := range statuses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var entries []string
+	for _, k := range keys {
+		entries = append(entries, fmt.Sprintf("%s:%s", k, statuses[k]))
+	}
+	return strings.Join(entries, ", ")
+}
+
+// truncateSSMOutput returns the last part of the command output.
+func truncateSSMOutput(s string) string {
+	return truncateTextStart(strings.TrimSpace(s), ssmMaxOutputLength)
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildSSMParameters(t *testing.T) {
+	var testcases = []struct {
+		name  string
+		input map[string]interface{}
+		want  map[string][]string
+	}{
+		{
+			name:  "test empty parameters",
+			input: map[string]interface{}{},
+			want:  map[string][]string{},
+		},
+		{
+			name: "test scalar and list parameters",
+			input: map[string]interface{}{
+				"commands":         []interface{}{"systemctl stop app", "systemctl start app"},
+				"executionTimeout": float64(3600),
+				"workingDirectory": "/opt/app",
+			},
+			want: map[string][]string{
+				"commands":         {"systemctl stop app", "systemctl start app"},
+				"executionTimeout": {"3600"},
+				"workingDirectory": {"/opt/app"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make(map[string][]string)
+			for k, v := range buildSSMParameters(tc.input) {
+				got[k] = aws.StringValueSlice(v)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
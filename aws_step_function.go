@@ -15,10 +15,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sfn"
@@ -28,9 +32,7 @@ import (
 
 // CheckIfStepFunctionExists checks whether a particular SageMaker Pipelines instance exists.
 func (ex *ExecutorPlugin) CheckIfStepFunctionExists(req *PluginRequest) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -68,9 +70,7 @@ func (ex *ExecutorPlugin) CheckIfStepFunctionExists(req *PluginRequest) *PluginR
 
 // StartStepFunctionExecution starts SageMaker Pipelines instance.
 func (ex *ExecutorPlugin) StartStepFunctionExecution(req *PluginRequest, workflowID string) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
-	})
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -79,6 +79,20 @@ func (ex *ExecutorPlugin) StartStepFunctionExecution(req *PluginRequest, workflo
 
 	sf := sfn.New(sess)
 
+	stateMachine, err := sf.DescribeStateMachine(&sfn.DescribeStateMachineInput{
+		StateMachineArn: aws.String(req.ResourceArn),
+	})
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to describe aws step function: %s", err),
+			Status:         2,
+		}
+	}
+
+	if aws.StringValue(stateMachine.Type) == sfn.StateMachineTypeExpress {
+		return ex.startStepFunctionSyncExecution(sess, req, stateMachine, workflowID)
+	}
+
 	params := &sfn.StartExecutionInput{
 		StateMachineArn: &req.ResourceArn,
 	}
@@ -112,9 +126,9 @@ func (ex *ExecutorPlugin) StartStepFunctionExecution(req *PluginRequest, workflo
 		zap.String("execution_arn", executionArn),
 	)
 
-	ex.Workflows[workflowID] = &PluginWorkflow{
+	ex.setWorkflow(req, workflowID, &PluginWorkflow{
 		ID: executionArn,
-	}
+	})
 
 	return &PluginResponse{
 		Message:       string(b),
@@ -126,11 +140,240 @@ func (ex *ExecutorPlugin) StartStepFunctionExecution(req *PluginRequest, workflo
 	}
 }
 
-// CheckStepFunctionExecution checks the status of SageMaker Pipelines execution.
-func (ex *ExecutorPlugin) CheckStepFunctionExecution(req *PluginRequest, executionID string) *PluginResponse {
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(req.RegionName),
+const (
+	// stepFunctionSyncExecutionTimeout bounds the synchronous execution of
+	// Express state machine, which runs for at most five minutes.
+	stepFunctionSyncExecutionTimeout = 6 * time.Minute
+	// stepFunctionSyncExecutionWait is the time the request waits for the
+	// result of the synchronous execution. It is below the 30 seconds timeout
+	// of the requests of the Argo agent.
+	stepFunctionSyncExecutionWait = 20 * time.Second
+)
+
+// buildStepFunctionExecutionInput returns the input of the synchronous
+// execution of Express state machine built from the parameters of the
+// request.
+func buildStepFunctionExecutionInput(req *PluginRequest) (*string, error) {
+	if len(req.Parameters) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(req.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build aws step function input: %s", err)
+	}
+	return aws.String(string(b)), nil
+}
+
+// startStepFunctionSyncExecution runs Express state machine with
+// StartSyncExecution and returns its result. When the execution outlasts the
+// wait, the node is requeued and the result is reported by the check of the
+// execution.
+func (ex *ExecutorPlugin) startStepFunctionSyncExecution(sess *session.Session, req *PluginRequest, stateMachine *sfn.DescribeStateMachineOutput, workflowID string) *PluginResponse {
+	input, err := buildStepFunctionExecutionInput(req)
+	if err != nil {
+		return &PluginResponse{
+			ExecutionError: err,
+			Status:         2,
+		}
+	}
+
+	wf := &PluginWorkflow{
+		Express:   true,
+		Status:    "RUNNING",
+		Message:   "running aws step function express execution",
+		StartedAt: time.Now().UTC(),
+	}
+
+	resp, completed := waitStepFunctionSyncExecution(func() (string, *PluginResponse) {
+		return ex.runStepFunctionSyncExecution(sess, req, stateMachine, input)
+	}, stepFunctionSyncExecutionWait, wf)
+	if completed {
+		return resp
+	}
+
+	ex.setWorkflow(req, workflowID, wf)
+
+	ex.Logger.Info("waiting for aws step function express execution",
+		zap.String("plugin_name", app.Name),
+		zap.String("state_machine_arn", req.ResourceArn),
+	)
+
+	return &PluginResponse{
+		Message:       "running aws step function express execution",
+		ShouldRequeue: true,
+		RequeueDuration: &metav1.Duration{
+			Duration: 5 * time.Second,
+		},
+		Status: 3,
+	}
+}
+
+// waitStepFunctionSyncExecution runs the synchronous execution and waits for
+// its result. It returns false when the wait elapses first, and the result is
+// then recorded in the workflow once the execution completes.
+func waitStepFunctionSyncExecution(run func() (string, *PluginResponse), wait time.Duration, wf *PluginWorkflow) (*PluginResponse, bool) {
+	type result struct {
+		status string
+		resp   *PluginResponse
+	}
+	done := make(chan result, 1)
+	go func() {
+		status, resp := run()
+		done <- result{status: status, resp: resp}
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.resp, true
+	case <-timer.C:
+	}
+
+	go func() {
+		r := <-done
+		wf.Lock()
+		defer wf.Unlock()
+		if r.resp.ExecutionError != nil {
+			wf.Status = "ERROR"
+			wf.Message = r.resp.ExecutionError.Error()
+			return
+		}
+		wf.Status = r.status
+		wf.Message = r.resp.Message
+		wf.Outputs = r.resp.Outputs
+	}()
+	return nil, false
+}
+
+// runStepFunctionSyncExecution runs Express state machine synchronously and
+// returns the status of the execution and the response for its result.
+func (ex *ExecutorPlugin) runStepFunctionSyncExecution(sess *session.Session, req *PluginRequest, stateMachine *sfn.DescribeStateMachineOutput, input *string) (string, *PluginResponse) {
+	ctx, cancel := context.WithTimeout(context.Background(), stepFunctionSyncExecutionTimeout)
+	defer cancel()
+
+	sf := sfn.New(sess)
+	output, err := sf.StartSyncExecutionWithContext(ctx, &sfn.StartSyncExecutionInput{
+		StateMachineArn: aws.String(req.ResourceArn),
+		Input:           input,
 	})
+	if err != nil {
+		return "ERROR", &PluginResponse{
+			ExecutionError: fmt.Errorf("failed to start aws step function sync execution: %s", err),
+			Status:         2,
+		}
+	}
+
+	status := aws.StringValue(output.Status)
+
+	ex.Logger.Info("completed aws step function sync execution",
+		zap.String("plugin_name", app.Name),
+		zap.String("execution_arn", aws.StringValue(output.ExecutionArn)),
+		zap.String("execution_status", status),
+	)
+
+	resp := buildStepFunctionSyncExecutionResponse(output)
+	if req.FetchLogs {
+		ex.addStepFunctionExecutionLogs(sess, req, stateMachine, aws.StringValue(output.ExecutionArn), aws.TimeValue(output.StartDate), resp)
+	}
+	return status, resp
+}
+
+// buildStepFunctionSyncExecutionResponse returns the response for the result
+// of the synchronous execution of Express state machine.
+func buildStepFunctionSyncExecutionResponse(output *sfn.StartSyncExecutionOutput) *PluginResponse {
+	status := aws.StringValue(output.Status)
+	outputs := map[string]string{
+		"execution_arn": aws.StringValue(output.ExecutionArn),
+		"output":        aws.StringValue(output.Output),
+		"error":         aws.StringValue(output.Error),
+		"cause":         aws.StringValue(output.Cause),
+	}
+
+	// SUCCEEDED | FAILED | TIMED_OUT
+
+	msg := fmt.Sprintf("aws step function express execution %s", status)
+	if status == sfn.SyncExecutionStatusSucceeded {
+		return &PluginResponse{
+			Message: msg,
+			Status:  1,
+			Outputs: outputs,
+		}
+	}
+
+	if output.Error != nil {
+		msg += fmt.Sprintf(": %s", aws.StringValue(output.Error))
+	}
+	if output.Cause != nil {
+		msg += fmt.Sprintf(": %s", aws.StringValue(output.Cause))
+	}
+	resp := &PluginResponse{
+		Message: msg,
+		Status:  4,
+		Outputs: outputs,
+	}
+	if status == sfn.SyncExecutionStatusTimedOut {
+		resp.FailureCategory = FailureTimeout
+	}
+	return resp
+}
+
+// checkStepFunctionSyncExecution returns the result of the synchronous
+// execution of Express state machine recorded in the workflow.
+func checkStepFunctionSyncExecution(wf *PluginWorkflow) *PluginResponse {
+	wf.Lock()
+	defer wf.Unlock()
+
+	switch wf.Status {
+	case sfn.SyncExecutionStatusSucceeded:
+		return &PluginResponse{
+			Message: wf.Message,
+			Status:  1,
+			Outputs: wf.Outputs,
+		}
+	case sfn.SyncExecutionStatusFailed:
+		return &PluginResponse{
+			Message: wf.Message,
+			Status:  4,
+			Outputs: wf.Outputs,
+		}
+	case sfn.SyncExecutionStatusTimedOut:
+		return &PluginResponse{
+			Message:         wf.Message,
+			Status:          4,
+			Outputs:         wf.Outputs,
+			FailureCategory: FailureTimeout,
+		}
+	case "ERROR":
+		return &PluginResponse{
+			ExecutionError: fmt.Errorf("%s", wf.Message),
+			Status:         2,
+		}
+	default:
+		// RUNNING
+		return &PluginResponse{
+			Message:       wf.Message,
+			ShouldRequeue: true,
+			RequeueDuration: &metav1.Duration{
+				Duration: 5 * time.Second,
+			},
+			Status: 3,
+		}
+	}
+}
+
+// CheckStepFunctionExecution checks the status of SageMaker Pipelines execution.
+func (ex *ExecutorPlugin) CheckStepFunctionExecution(req *PluginRequest, wf *PluginWorkflow) *PluginResponse {
+	// The express executions that outlast the start request run in the
+	// background and record their result, while the standard executions are
+	// described.
+	if wf.Express {
+		return checkStepFunctionSyncExecution(wf)
+	}
+	executionID := wf.ID
+
+	sess, err := newAWSSession(req)
 	if err != nil {
 		return &PluginResponse{
 			ExecutionError: fmt.Errorf("failed to create aws session: %s", err),
@@ -170,24 +413,422 @@ func (ex *ExecutorPlugin) CheckStepFunctionExecution(req *PluginRequest, executi
 
 	switch *output.Status {
 	case "SUCCEEDED":
-		return &PluginResponse{
+		resp := &PluginResponse{
 			Message: string(b),
 			Status:  1,
 		}
+		if req.FetchLogs {
+			ex.fetchStepFunctionExecutionLogs(sess, sf, req, output, resp)
+		}
+		return resp
 	case "TIMED_OUT", "FAILED", "ABORTED":
-		return &PluginResponse{
-			Message: string(b),
-			Status:  2,
+		var category FailureCategory
+		if *output.Status == sfn.ExecutionStatusTimedOut {
+			category = FailureTimeout
+		}
+		failure, err := getStepFunctionExecutionFailure(sf, executionID)
+		if err != nil {
+			ex.Logger.Warn("failed to get aws step function execution history",
+				zap.String("plugin_name", app.Name),
+				zap.String("execution_arn", executionID),
+				zap.Error(err),
+			)
+			resp := &PluginResponse{
+				Message:         string(b),
+				Status:          4,
+				FailureCategory: category,
+			}
+			if req.FetchLogs {
+				ex.fetchStepFunctionExecutionLogs(sess, sf, req, output, resp)
+			}
+			return resp
+		}
+		resp := &PluginResponse{
+			Message:         fmt.Sprintf("aws step function execution %s: %s", aws.StringValue(output.Status), failure),
+			Status:          4,
+			Outputs:         failure.outputs(),
+			FailureCategory: category,
+		}
+		if req.FetchLogs {
+			ex.fetchStepFunctionExecutionLogs(sess, sf, req, output, resp)
 		}
+		return resp
 	default:
 		// Covers Stopping and Executing
+		if wf.mapProgress == nil {
+			wf.mapProgress = newStepFunctionMapProgress()
+		}
+		progress, err := wf.mapProgress.update(sf, executionID)
+		if err != nil {
+			ex.Logger.Warn("failed to get aws step function map progress",
+				zap.String("plugin_name", app.Name),
+				zap.String("execution_arn", executionID),
+				zap.Error(err),
+			)
+		}
 		return &PluginResponse{
 			Message:       string(b),
 			ShouldRequeue: true,
 			RequeueDuration: &metav1.Duration{
 				Duration: 60 * time.Second,
 			},
-			Status: 3,
+			Status:   3,
+			Progress: progress,
+		}
+	}
+}
+
+// stepFunctionMapProgress tracks the progress of the Map states of AWS Step
+// Functions execution across the checks. The history events are read
+// incrementally, and the item counts of the finished map runs are cached.
+type stepFunctionMapProgress struct {
+	lastEventID int64
+	completed   int64
+	total       int64
+	mapRunArns  []string
+	mapRuns     map[string]*sfn.MapRunItemCounts
+}
+
+// newStepFunctionMapProgress returns the progress of the execution that has
+// not been read yet.
+func newStepFunctionMapProgress() *stepFunctionMapProgress {
+	return &stepFunctionMapProgress{
+		mapRuns: make(map[string]*sfn.MapRunItemCounts),
+	}
+}
+
+// update returns the number of completed iterations out of all iterations of
+// the Map states of AWS Step Functions execution. The history is read newest
+// first and stops at the last event read by the previous update. The progress
+// of distributed Map states is taken from their map runs, and the map runs
+// that are no longer running are not described again.
+func (p *stepFunctionMapProgress) update(sf *sfn.SFN, executionID string) (wfv1.Progress, error) {
+	var events []*sfn.HistoryEvent
+	if err := sf.GetExecutionHistoryPages(&sfn.GetExecutionHistoryInput{
+		ExecutionArn: aws.String(executionID),
+		MaxResults:   aws.Int64(1000),
+		ReverseOrder: aws.Bool(true),
+	}, func(page *sfn.GetExecutionHistoryOutput, lastPage bool) bool {
+		for _, event := range page.Events {
+			if aws.Int64Value(event.Id) <= p.lastEventID {
+				return false
+			}
+			events = append(events, event)
+		}
+		return true
+	}); err != nil {
+		return wfv1.ProgressUndefined, fmt.Errorf("failed to get aws step function execution history: %s", err)
+	}
+	p.addEvents(events)
+
+	completed, total := p.completed, p.total
+	for _, mapRunArn := range p.mapRunArns {
+		counts, exists := p.mapRuns[mapRunArn]
+		if !exists {
+			output, err := sf.DescribeMapRun(&sfn.DescribeMapRunInput{
+				MapRunArn: aws.String(mapRunArn),
+			})
+			if err != nil {
+				return wfv1.ProgressUndefined, fmt.Errorf("failed to describe aws step function map run: %s", err)
+			}
+			counts = output.ItemCounts
+			if aws.StringValue(output.Status) != sfn.MapRunStatusRunning {
+				p.mapRuns[mapRunArn] = counts
+			}
+		}
+		if counts != nil {
+			completed += aws.Int64Value(counts.Succeeded) + aws.Int64Value(counts.Failed) +
+				aws.Int64Value(counts.TimedOut) + aws.Int64Value(counts.Aborted)
+			total += aws.Int64Value(counts.Total)
+		}
+	}
+	return newProgress(completed, total), nil
+}
+
+// addEvents counts the Map state iterations of the history events. The events
+// read by the previous updates are skipped.
+func (p *stepFunctionMapProgress) addEvents(events []*sfn.HistoryEvent) {
+	var newEvents []*sfn.HistoryEvent
+	lastEventID := p.lastEventID
+	for _, event := range events {
+		id := aws.Int64Value(event.Id)
+		if id <= p.lastEventID {
+			continue
+		}
+		newEvents = append(newEvents, event)
+		if id > lastEventID {
+			lastEventID = id
+		}
+	}
+	p.lastEventID = lastEventID
+	completed, total, mapRunArns := countStepFunctionMapIterations(newEvents)
+	p.completed += completed
+	p.total += total
+	p.mapRunArns = append(p.mapRunArns, mapRunArns...)
+}
+
+// countStepFunctionMapIterations returns the number of completed iterations
+// and all iterations of inline Map states, and the ARNs of the map runs of
+// distributed Map states.
+func countStepFunctionMapIterations(events []*sfn.HistoryEvent) (int64, int64, []string) {
+	var completed, total int64
+	var mapRunArns []string
+	for _, event := range events {
+		switch aws.StringValue(event.Type) {
+		case sfn.HistoryEventTypeMapStateStarted:
+			if event.MapStateStartedEventDetails != nil {
+				total += aws.Int64Value(event.MapStateStartedEventDetails.Length)
+			}
+		case sfn.HistoryEventTypeMapIterationSucceeded, sfn.HistoryEventTypeMapIterationFailed, sfn.HistoryEventTypeMapIterationAborted:
+			completed++
+		case sfn.HistoryEventTypeMapRunStarted:
+			if event.MapRunStartedEventDetails != nil {
+				mapRunArns = append(mapRunArns, aws.StringValue(event.MapRunStartedEventDetails.MapRunArn))
+			}
+		}
+	}
+	return completed, total, mapRunArns
+}
+
+// fetchStepFunctionExecutionLogs adds the logs of AWS Step Functions
+// execution to the response.
+func (ex *ExecutorPlugin) fetchStepFunctionExecutionLogs(sess *session.Session, sf *sfn.SFN, req *PluginRequest, execution *sfn.DescribeExecutionOutput, resp *PluginResponse) {
+	stateMachine, err := sf.DescribeStateMachine(&sfn.DescribeStateMachineInput{
+		StateMachineArn: execution.StateMachineArn,
+	})
+	if err != nil {
+		ex.Logger.Warn("failed to describe aws step function",
+			zap.String("plugin_name", app.Name),
+			zap.String("execution_arn", aws.StringValue(execution.ExecutionArn)),
+			zap.Error(err),
+		)
+		return
+	}
+	ex.addStepFunctionExecutionLogs(sess, req, stateMachine, aws.StringValue(execution.ExecutionArn), aws.TimeValue(execution.StartDate), resp)
+}
+
+// addStepFunctionExecutionLogs adds the last lines of the logs of AWS Step
+// Functions execution written to the log group of the state machine to the
+// outputs of the response, and to the message of failed response.
+func (ex *ExecutorPlugin) addStepFunctionExecutionLogs(sess *session.Session, req *PluginRequest, stateMachine *sfn.DescribeStateMachineOutput, executionArn string, startTime time.Time, resp *PluginResponse) {
+	var logGroupName string
+	if stateMachine.LoggingConfiguration != nil {
+		for _, destination := range stateMachine.LoggingConfiguration.Destinations {
+			if destination.CloudWatchLogsLogGroup != nil {
+				logGroupName = getLogGroupNameFromArn(aws.StringValue(destination.CloudWatchLogsLogGroup.LogGroupArn))
+				break
+			}
+		}
+	}
+	if logGroupName == "" {
+		ex.Logger.Warn("aws step function has no logging configuration",
+			zap.String("plugin_name", app.Name),
+			zap.String("state_machine_arn", aws.StringValue(stateMachine.StateMachineArn)),
+		)
+		return
+	}
+
+	lines, err := filterCloudWatchLogEvents(sess, logGroupName, fmt.Sprintf("{ $.execution_arn = %q }", executionArn), startTime, getLogTailLines(req))
+	if err != nil {
+		ex.Logger.Warn("failed to fetch aws step function execution logs",
+			zap.String("plugin_name", app.Name),
+			zap.String("execution_arn", executionArn),
+			zap.Error(err),
+		)
+		return
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	if resp.Outputs == nil {
+		resp.Outputs = make(map[string]string)
+	}
+	resp.Outputs["log_tail"] = strings.Join(lines, "\n")
+	if resp.Status != 1 {
+		resp.Message += fmt.Sprintf("\nlast %d lines of log:\n%s", len(lines), resp.Outputs["log_tail"])
+	}
+}
+
+const (
+	// stepFunctionMaxCauseLength is the maximum length of the failure cause
+	// included in the node message.
+	stepFunctionMaxCauseLength = 256
+	// stepFunctionMaxFailureEvents is the maximum number of the most recent
+	// history events read when looking for the failure of the execution.
+	stepFunctionMaxFailureEvents = 5000
+	// stepFunctionMaxFailureDuration is the maximum duration of reading the
+	// history when looking for the failure of the execution.
+	stepFunctionMaxFailureDuration = 15 * time.Second
+)
+
+// stepFunctionFailure describes the failure of AWS Step Functions execution.
+type stepFunctionFailure struct {
+	State     string
+	Error     string
+	Cause     string
+	Iteration int64
+	InMap     bool
+}
+
+// String returns concise description of the failure.
+func (f *stepFunctionFailure) String() string {
+	var sb strings.Builder
+	if f.State != "" {
+		sb.WriteString(fmt.Sprintf("state %q", f.State))
+	} else {
+		sb.WriteString("execution")
+	}
+	if f.InMap {
+		sb.WriteString(fmt.Sprintf(" in map iteration %d", f.Iteration))
+	}
+	sb.WriteString(" failed")
+	if f.Error != "" {
+		sb.WriteString(" with " + f.Error)
+	}
+	if f.Cause != "" {
+		sb.WriteString(": " + truncateText(f.Cause, stepFunctionMaxCauseLength))
+	}
+	return sb.String()
+}
+
+func (f *stepFunctionFailure) outputs() map[string]string {
+	outputs := map[string]string{
+		"failed_state": f.State,
+		"error":        f.Error,
+		"cause":        f.Cause,
+	}
+	if f.InMap {
+		outputs["map_iteration"] = strconv.FormatInt(f.Iteration, 10)
+	}
+	return outputs
+}
+
+// getStepFunctionExecutionFailure reads the history of AWS Step Functions
+// execution and returns the description of its failure.
+func getStepFunctionExecutionFailure(sf *sfn.SFN, executionID string) (*stepFunctionFailure, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stepFunctionMaxFailureDuration)
+	defer cancel()
+
+	// The history of long running execution may have many events, so only
+	// the most recent events, where the failure is, are read.
+	var events []*sfn.HistoryEvent
+	if err := sf.GetExecutionHistoryPagesWithContext(ctx, &sfn.GetExecutionHistoryInput{
+		ExecutionArn: aws.String(executionID),
+		MaxResults:   aws.Int64(1000),
+		ReverseOrder: aws.Bool(true),
+	}, func(page *sfn.GetExecutionHistoryOutput, lastPage bool) bool {
+		events = append(events, page.Events...)
+		return len(events) < stepFunctionMaxFailureEvents
+	}); err != nil {
+		if ctx.Err() == nil || len(events) == 0 {
+			return nil, err
+		}
+	}
+	return findStepFunctionFailure(reverseStepFunctionHistoryEvents(events)), nil
+}
+
+// reverseStepFunctionHistoryEvents returns the history events in reverse
+// order.
+func reverseStepFunctionHistoryEvents(events []*sfn.HistoryEvent) []*sfn.HistoryEvent {
+	reversed := make([]*sfn.HistoryEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		reversed = append(reversed, events[i])
+	}
+	return reversed
+}
+
+// findStepFunctionFailure returns the description of the failure from the
+// execution history. It prefers the most recent failure of a task when its
+// error failed the execution, and falls back to the failure of the execution
+// itself. The name of the failed state
+// and the map iteration are found by following the chain of previous events.
+func findStepFunctionFailure(events []*sfn.HistoryEvent) *stepFunctionFailure {
+	byID := make(map[int64]*sfn.HistoryEvent)
+	for _, event := range events {
+		byID[aws.Int64Value(event.Id)] = event
+	}
+
+	failure := &stepFunctionFailure{}
+
+	var failed *sfn.HistoryEvent
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		switch {
+		case event.ExecutionFailedEventDetails != nil:
+			failure.Error = aws.StringValue(event.ExecutionFailedEventDetails.Error)
+			failure.Cause = aws.StringValue(event.ExecutionFailedEventDetails.Cause)
+		case event.ExecutionTimedOutEventDetails != nil:
+			failure.Error = aws.StringValue(event.ExecutionTimedOutEventDetails.Error)
+			failure.Cause = aws.StringValue(event.ExecutionTimedOutEventDetails.Cause)
+		case event.ExecutionAbortedEventDetails != nil:
+			failure.Error = aws.StringValue(event.ExecutionAbortedEventDetails.Error)
+			failure.Cause = aws.StringValue(event.ExecutionAbortedEventDetails.Cause)
+		default:
+			continue
 		}
+		failed = event
+		break
+	}
+
+	// The task failure is the reason of the execution failure when the error
+	// propagated to the execution. Otherwise, the task failure was caught
+	// and the execution failed in a Fail state.
+	for i := len(events) - 1; i >= 0; i-- {
+		errorName, cause, ok := getStepFunctionTaskFailure(events[i])
+		if !ok {
+			continue
+		}
+		if failed == nil || failure.Error == "" || failure.Error == errorName {
+			failed = events[i]
+			failure.Error = errorName
+			failure.Cause = cause
+		}
+		break
+	}
+
+	for event := failed; event != nil; event = byID[aws.Int64Value(event.PreviousEventId)] {
+		if failure.State == "" && event.StateEnteredEventDetails != nil {
+			failure.State = aws.StringValue(event.StateEnteredEventDetails.Name)
+		}
+		if event.MapIterationStartedEventDetails != nil {
+			failure.InMap = true
+			failure.Iteration = aws.Int64Value(event.MapIterationStartedEventDetails.Index)
+			break
+		}
+	}
+
+	return failure
+}
+
+// getStepFunctionTaskFailure returns the error and the cause of the event
+// when the event is the failure of a task, an activity or a lambda function.
+func getStepFunctionTaskFailure(event *sfn.HistoryEvent) (string, string, bool) {
+	switch {
+	case event.TaskFailedEventDetails != nil:
+		return aws.StringValue(event.TaskFailedEventDetails.Error), aws.StringValue(event.TaskFailedEventDetails.Cause), true
+	case event.TaskTimedOutEventDetails != nil:
+		return aws.StringValue(event.TaskTimedOutEventDetails.Error), aws.StringValue(event.TaskTimedOutEventDetails.Cause), true
+	case event.TaskStartFailedEventDetails != nil:
+		return aws.StringValue(event.TaskStartFailedEventDetails.Error), aws.StringValue(event.TaskStartFailedEventDetails.Cause), true
+	case event.TaskSubmitFailedEventDetails != nil:
+		return aws.StringValue(event.TaskSubmitFailedEventDetails.Error), aws.StringValue(event.TaskSubmitFailedEventDetails.Cause), true
+	case event.LambdaFunctionFailedEventDetails != nil:
+		return aws.StringValue(event.LambdaFunctionFailedEventDetails.Error), aws.StringValue(event.LambdaFunctionFailedEventDetails.Cause), true
+	case event.LambdaFunctionTimedOutEventDetails != nil:
+		return aws.StringValue(event.LambdaFunctionTimedOutEventDetails.Error), aws.StringValue(event.LambdaFunctionTimedOutEventDetails.Cause), true
+	case event.LambdaFunctionStartFailedEventDetails != nil:
+		return aws.StringValue(event.LambdaFunctionStartFailedEventDetails.Error), aws.StringValue(event.LambdaFunctionStartFailedEventDetails.Cause), true
+	case event.LambdaFunctionScheduleFailedEventDetails != nil:
+		return aws.StringValue(event.LambdaFunctionScheduleFailedEventDetails.Error), aws.StringValue(event.LambdaFunctionScheduleFailedEventDetails.Cause), true
+	case event.ActivityFailedEventDetails != nil:
+		return aws.StringValue(event.ActivityFailedEventDetails.Error), aws.StringValue(event.ActivityFailedEventDetails.Cause), true
+	case event.ActivityTimedOutEventDetails != nil:
+		return aws.StringValue(event.ActivityTimedOutEventDetails.Error), aws.StringValue(event.ActivityTimedOutEventDetails.Cause), true
+	case event.ActivityScheduleFailedEventDetails != nil:
+		return aws.StringValue(event.ActivityScheduleFailedEventDetails.Error), aws.StringValue(event.ActivityScheduleFailedEventDetails.Cause), true
+	case event.MapRunFailedEventDetails != nil:
+		return aws.StringValue(event.MapRunFailedEventDetails.Error), aws.StringValue(event.MapRunFailedEventDetails.Cause), true
 	}
+	return "", "", false
 }
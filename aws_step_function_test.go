@@ -0,0 +1,433 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindStepFunctionFailure(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		events []*sfn.HistoryEvent
+		want   *stepFunctionFailure
+	}{
+		{
+			name: "test task failure in map iteration",
+			events: []*sfn.HistoryEvent{
+				{Id: aws.Int64(1), Type: aws.String("ExecutionStarted")},
+				{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("MapStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("ProcessFiles")}},
+				{Id: aws.Int64(3), PreviousEventId: aws.Int64(2), Type: aws.String("MapStateStarted")},
+				{Id: aws.Int64(4), PreviousEventId: aws.Int64(3), Type: aws.String("MapIterationStarted"), MapIterationStartedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+				{Id: aws.Int64(5), PreviousEventId: aws.Int64(3), Type: aws.String("MapIterationStarted"), MapIterationStartedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(2)}},
+				{Id: aws.Int64(6), PreviousEventId: aws.Int64(5), Type: aws.String("TaskStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("Transform")}},
+				{Id: aws.Int64(7), PreviousEventId: aws.Int64(6), Type: aws.String("TaskScheduled")},
+				{Id: aws.Int64(8), PreviousEventId: aws.Int64(7), Type: aws.String("TaskFailed"), TaskFailedEventDetails: &sfn.TaskFailedEventDetails{Error: aws.String("States.TaskFailed"), Cause: aws.String("file is corrupted")}},
+				{Id: aws.Int64(9), PreviousEventId: aws.Int64(8), Type: aws.String("MapIterationFailed"), MapIterationFailedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(2)}},
+				{Id: aws.Int64(10), PreviousEventId: aws.Int64(9), Type: aws.String("MapStateFailed")},
+				{Id: aws.Int64(11), PreviousEventId: aws.Int64(10), Type: aws.String("ExecutionFailed"), ExecutionFailedEventDetails: &sfn.ExecutionFailedEventDetails{Error: aws.String("States.TaskFailed"), Cause: aws.String("file is corrupted")}},
+			},
+			want: &stepFunctionFailure{
+				State:     "Transform",
+				Error:     "States.TaskFailed",
+				Cause:     "file is corrupted",
+				Iteration: 2,
+				InMap:     true,
+			},
+		},
+		{
+			name: "test caught task failure followed by fail state",
+			events: []*sfn.HistoryEvent{
+				{Id: aws.Int64(1), Type: aws.String("ExecutionStarted")},
+				{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("TaskStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("Load")}},
+				{Id: aws.Int64(3), PreviousEventId: aws.Int64(2), Type: aws.String("LambdaFunctionFailed"), LambdaFunctionFailedEventDetails: &sfn.LambdaFunctionFailedEventDetails{Error: aws.String("Lambda.Unknown"), Cause: aws.String("out of memory")}},
+				{Id: aws.Int64(4), PreviousEventId: aws.Int64(3), Type: aws.String("TaskStateExited")},
+				{Id: aws.Int64(5), PreviousEventId: aws.Int64(4), Type: aws.String("FailStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("LoadFailed")}},
+				{Id: aws.Int64(6), PreviousEventId: aws.Int64(5), Type: aws.String("ExecutionFailed"), ExecutionFailedEventDetails: &sfn.ExecutionFailedEventDetails{Error: aws.String("LoadError"), Cause: aws.String("failed to load data")}},
+			},
+			want: &stepFunctionFailure{
+				State: "LoadFailed",
+				Error: "LoadError",
+				Cause: "failed to load data",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findStepFunctionFailure(tc.events)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestCountStepFunctionMapIterations(t *testing.T) {
+	var testcases = []struct {
+		name          string
+		events        []*sfn.HistoryEvent
+		wantCompleted int64
+		wantTotal     int64
+		wantMapRuns   []string
+	}{
+		{
+			name: "test execution without map states",
+			events: []*sfn.HistoryEvent{
+				{Id: aws.Int64(1), Type: aws.String("ExecutionStarted")},
+				{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("TaskStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("Load")}},
+			},
+		},
+		{
+			name: "test inline and distributed map states",
+			events: []*sfn.HistoryEvent{
+				{Id: aws.Int64(1), Type: aws.String("ExecutionStarted")},
+				{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("MapStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("ProcessFiles")}},
+				{Id: aws.Int64(3), PreviousEventId: aws.Int64(2), Type: aws.String("MapStateStarted"), MapStateStartedEventDetails: &sfn.MapStateStartedEventDetails{Length: aws.Int64(3)}},
+				{Id: aws.Int64(4), PreviousEventId: aws.Int64(3), Type: aws.String("MapIterationStarted"), MapIterationStartedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+				{Id: aws.Int64(5), PreviousEventId: aws.Int64(3), Type: aws.String("MapIterationStarted"), MapIterationStartedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(1)}},
+				{Id: aws.Int64(6), PreviousEventId: aws.Int64(4), Type: aws.String("MapIterationSucceeded"), MapIterationSucceededEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+				{Id: aws.Int64(7), PreviousEventId: aws.Int64(5), Type: aws.String("MapIterationFailed"), MapIterationFailedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(1)}},
+				{Id: aws.Int64(8), PreviousEventId: aws.Int64(1), Type: aws.String("MapRunStarted"), MapRunStartedEventDetails: &sfn.MapRunStartedEventDetails{MapRunArn: aws.String("arn:aws:states:us-east-1:123456789012:mapRun:foo/bar:baz")}},
+			},
+			wantCompleted: 2,
+			wantTotal:     3,
+			wantMapRuns:   []string{"arn:aws:states:us-east-1:123456789012:mapRun:foo/bar:baz"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			completed, total, mapRuns := countStepFunctionMapIterations(tc.events)
+			got := map[string]interface{}{
+				"completed": completed,
+				"total":     total,
+				"map_runs":  mapRuns,
+			}
+			want := map[string]interface{}{
+				"completed": tc.wantCompleted,
+				"total":     tc.wantTotal,
+				"map_runs":  tc.wantMapRuns,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestStepFunctionMapProgressAddEvents(t *testing.T) {
+	var testcases = []struct {
+		name            string
+		batches         [][]*sfn.HistoryEvent
+		wantCompleted   int64
+		wantTotal       int64
+		wantLastEventID int64
+		wantMapRuns     []string
+	}{
+		{
+			name: "test newer events read in reverse order",
+			batches: [][]*sfn.HistoryEvent{
+				{
+					{Id: aws.Int64(3), PreviousEventId: aws.Int64(2), Type: aws.String("MapStateStarted"), MapStateStartedEventDetails: &sfn.MapStateStartedEventDetails{Length: aws.Int64(2)}},
+					{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("MapStateEntered"), StateEnteredEventDetails: &sfn.StateEnteredEventDetails{Name: aws.String("ProcessFiles")}},
+					{Id: aws.Int64(1), Type: aws.String("ExecutionStarted")},
+				},
+				{
+					{Id: aws.Int64(6), PreviousEventId: aws.Int64(5), Type: aws.String("MapRunStarted"), MapRunStartedEventDetails: &sfn.MapRunStartedEventDetails{MapRunArn: aws.String("arn:aws:states:us-east-1:123456789012:mapRun:foo/bar:baz")}},
+					{Id: aws.Int64(5), PreviousEventId: aws.Int64(4), Type: aws.String("MapIterationSucceeded"), MapIterationSucceededEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+					{Id: aws.Int64(4), PreviousEventId: aws.Int64(3), Type: aws.String("MapIterationStarted"), MapIterationStartedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+				},
+			},
+			wantCompleted:   1,
+			wantTotal:       2,
+			wantLastEventID: 6,
+			wantMapRuns:     []string{"arn:aws:states:us-east-1:123456789012:mapRun:foo/bar:baz"},
+		},
+		{
+			name: "test events read by previous update are skipped",
+			batches: [][]*sfn.HistoryEvent{
+				{
+					{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("MapStateStarted"), MapStateStartedEventDetails: &sfn.MapStateStartedEventDetails{Length: aws.Int64(3)}},
+					{Id: aws.Int64(3), PreviousEventId: aws.Int64(2), Type: aws.String("MapIterationSucceeded"), MapIterationSucceededEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+				},
+				{
+					{Id: aws.Int64(4), PreviousEventId: aws.Int64(2), Type: aws.String("MapIterationFailed"), MapIterationFailedEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(1)}},
+					{Id: aws.Int64(3), PreviousEventId: aws.Int64(2), Type: aws.String("MapIterationSucceeded"), MapIterationSucceededEventDetails: &sfn.MapIterationEventDetails{Name: aws.String("ProcessFiles"), Index: aws.Int64(0)}},
+					{Id: aws.Int64(2), PreviousEventId: aws.Int64(1), Type: aws.String("MapStateStarted"), MapStateStartedEventDetails: &sfn.MapStateStartedEventDetails{Length: aws.Int64(3)}},
+				},
+			},
+			wantCompleted:   2,
+			wantTotal:       3,
+			wantLastEventID: 4,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newStepFunctionMapProgress()
+			for _, events := range tc.batches {
+				p.addEvents(events)
+			}
+			got := map[string]interface{}{
+				"completed":     p.completed,
+				"total":         p.total,
+				"last_event_id": p.lastEventID,
+				"map_runs":      p.mapRunArns,
+			}
+			want := map[string]interface{}{
+				"completed":     tc.wantCompleted,
+				"total":         tc.wantTotal,
+				"last_event_id": tc.wantLastEventID,
+				"map_runs":      tc.wantMapRuns,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestBuildStepFunctionExecutionInput(t *testing.T) {
+	var testcases = []struct {
+		name string
+		req  *PluginRequest
+		want *string
+	}{
+		{
+			name: "test request without parameters",
+			req:  &PluginRequest{},
+			want: nil,
+		},
+		{
+			name: "test request with parameters",
+			req: &PluginRequest{
+				Parameters: map[string]interface{}{
+					"bucket": "foo",
+					"count":  2,
+				},
+			},
+			want: aws.String(`{"bucket":"foo","count":2}`),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := buildStepFunctionExecutionInput(tc.req)
+			if err != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestStepFunctionSyncExecution(t *testing.T) {
+	var testcases = []struct {
+		name   string
+		output *sfn.StartSyncExecutionOutput
+		want   *PluginResponse
+	}{
+		{
+			name: "test succeeded express execution",
+			output: &sfn.StartSyncExecutionOutput{
+				ExecutionArn: aws.String("arn:aws:states:us-west-2:100000000002:express:MyStateMachine:foo:bar"),
+				Status:       aws.String(sfn.SyncExecutionStatusSucceeded),
+				Output:       aws.String(`{"count":2}`),
+			},
+			want: &PluginResponse{
+				Message: "aws step function express execution SUCCEEDED",
+				Status:  1,
+				Outputs: map[string]string{
+					"execution_arn": "arn:aws:states:us-west-2:100000000002:express:MyStateMachine:foo:bar",
+					"output":        `{"count":2}`,
+					"error":         "",
+					"cause":         "",
+				},
+			},
+		},
+		{
+			name: "test failed express execution",
+			output: &sfn.StartSyncExecutionOutput{
+				ExecutionArn: aws.String("arn:aws:states:us-west-2:100000000002:express:MyStateMachine:foo:bar"),
+				Status:       aws.String(sfn.SyncExecutionStatusFailed),
+				Error:        aws.String("States.TaskFailed"),
+				Cause:        aws.String("file not found"),
+			},
+			want: &PluginResponse{
+				Message: "aws step function express execution FAILED: States.TaskFailed: file not found",
+				Status:  4,
+				Outputs: map[string]string{
+					"execution_arn": "arn:aws:states:us-west-2:100000000002:express:MyStateMachine:foo:bar",
+					"output":        "",
+					"error":         "States.TaskFailed",
+					"cause":         "file not found",
+				},
+			},
+		},
+		{
+			name: "test timed out express execution",
+			output: &sfn.StartSyncExecutionOutput{
+				ExecutionArn: aws.String("arn:aws:states:us-west-2:100000000002:express:MyStateMachine:foo:bar"),
+				Status:       aws.String(sfn.SyncExecutionStatusTimedOut),
+			},
+			want: &PluginResponse{
+				Message: "aws step function express execution TIMED_OUT",
+				Status:  4,
+				Outputs: map[string]string{
+					"execution_arn": "arn:aws:states:us-west-2:100000000002:express:MyStateMachine:foo:bar",
+					"output":        "",
+					"error":         "",
+					"cause":         "",
+				},
+				FailureCategory: FailureTimeout,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildStepFunctionSyncExecutionResponse(tc.output)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+
+			// The result recorded by the background execution is reported
+			// by the check of the execution.
+			wf := &PluginWorkflow{
+				Status:  aws.StringValue(tc.output.Status),
+				Message: got.Message,
+				Outputs: got.Outputs,
+			}
+			if diff := cmp.Diff(tc.want, checkStepFunctionSyncExecution(wf)); diff != "" {
+				t.Fatalf("test name: %s, unexpected check result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestCheckStepFunctionSyncExecution(t *testing.T) {
+	var testcases = []struct {
+		name string
+		wf   *PluginWorkflow
+		want map[string]interface{}
+	}{
+		{
+			name: "test running express execution",
+			wf: &PluginWorkflow{
+				Status:  "RUNNING",
+				Message: "running aws step function express execution",
+			},
+			want: map[string]interface{}{
+				"status":         RUNNING,
+				"message":        "running aws step function express execution",
+				"should_requeue": true,
+			},
+		},
+		{
+			name: "test express execution not started",
+			wf: &PluginWorkflow{
+				Status:  "ERROR",
+				Message: "failed to start aws step function sync execution: AccessDeniedException: denied",
+			},
+			want: map[string]interface{}{
+				"status":          ERROR,
+				"execution_error": fmt.Errorf("failed to start aws step function sync execution: AccessDeniedException: denied").Error(),
+				"should_requeue":  false,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := checkStepFunctionSyncExecution(tc.wf)
+			got := map[string]interface{}{
+				"status":         resp.Status,
+				"should_requeue": resp.ShouldRequeue,
+			}
+			if resp.Message != "" {
+				got["message"] = resp.Message
+			}
+			if resp.ExecutionError != nil {
+				got["execution_error"] = resp.ExecutionError.Error()
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestWaitStepFunctionSyncExecution(t *testing.T) {
+	resp := &PluginResponse{
+		Message: "aws step function express execution SUCCEEDED",
+		Status:  1,
+		Outputs: map[string]string{
+			"output": `{"count":2}`,
+		},
+	}
+
+	t.Run("test execution completed within wait", func(t *testing.T) {
+		wf := &PluginWorkflow{Express: true, Status: "RUNNING"}
+		got, completed := waitStepFunctionSyncExecution(func() (string, *PluginResponse) {
+			return sfn.SyncExecutionStatusSucceeded, resp
+		}, time.Minute, wf)
+		if !completed {
+			t.Fatalf("expected execution to complete within wait")
+		}
+		if diff := cmp.Diff(resp, got); diff != "" {
+			t.Fatalf("unexpected result (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("test execution outlasting wait", func(t *testing.T) {
+		wf := &PluginWorkflow{Express: true, Status: "RUNNING"}
+		release := make(chan struct{})
+		_, completed := waitStepFunctionSyncExecution(func() (string, *PluginResponse) {
+			<-release
+			return sfn.SyncExecutionStatusSucceeded, resp
+		}, 10*time.Millisecond, wf)
+		if completed {
+			t.Fatalf("expected execution to outlast wait")
+		}
+		if got := checkStepFunctionSyncExecution(wf); got.Status != RUNNING {
+			t.Fatalf("unexpected status before completion: %v", got.Status)
+		}
+		close(release)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			got := checkStepFunctionSyncExecution(wf)
+			if got.Status != RUNNING {
+				if diff := cmp.Diff(resp, got); diff != "" {
+					t.Fatalf("unexpected result (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("execution result not recorded")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
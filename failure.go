@@ -0,0 +1,153 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+)
+
+// FailureCategory identifies the cause of failed plugin request.
+type FailureCategory string
+
+const (
+	// FailureUserError identifies invalid input, e.g. parameters rejected by
+	// AWS service.
+	FailureUserError FailureCategory = "user_error"
+	// FailureThrottled identifies requests throttled by AWS service.
+	FailureThrottled FailureCategory = "throttled"
+	// FailurePermissionDenied identifies requests denied by AWS IAM.
+	FailurePermissionDenied FailureCategory = "permission_denied"
+	// FailureResourceMissing identifies requests referencing AWS resources
+	// that do not exist.
+	FailureResourceMissing FailureCategory = "resource_missing"
+	// FailureRemoteJobFailed identifies jobs that ran and failed in AWS.
+	FailureRemoteJobFailed FailureCategory = "remote_job_failed"
+	// FailureTimeout identifies jobs and waits that timed out.
+	FailureTimeout FailureCategory = "timeout"
+	// FailureConditionFailed identifies conditional writes rejected because
+	// their condition is not met, e.g. the lock is held by another workflow.
+	FailureConditionFailed FailureCategory = "condition_failed"
+	// FailureInternalError identifies other errors, e.g. plugin errors.
+	FailureInternalError FailureCategory = "internal_error"
+)
+
+// failureErrorCodes maps the error codes returned by AWS services to failure
+// categories.
+var failureErrorCodes = []struct {
+	category FailureCategory
+	codes    []string
+}{
+	{
+		category: FailureThrottled,
+		codes: []string{
+			"Throttling", "ThrottlingException", "ThrottledException", "TooManyRequestsException",
+			"RequestLimitExceeded", "RequestThrottled", "RequestThrottledException",
+			"ProvisionedThroughputExceededException", "SlowDown", "ConcurrentRunsExceededException",
+		},
+	},
+	{
+		category: FailurePermissionDenied,
+		codes: []string{
+			"AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "UnauthorizedException",
+			"AuthorizationError", "AuthFailure", "ExpiredToken", "ExpiredTokenException",
+			"InvalidClientTokenId", "UnrecognizedClientException", "KMSAccessDeniedException",
+		},
+	},
+	{
+		category: FailureResourceMissing,
+		codes: []string{
+			"ResourceNotFoundException", "ResourceNotFound", "EntityNotFoundException", "NotFound",
+			"NoSuchBucket", "NoSuchKey", "StateMachineDoesNotExist", "ExecutionDoesNotExist",
+			"InvalidDocument", "InvalidInstanceID.NotFound", "DBInstanceNotFound", "DBClusterNotFoundFault",
+			"DBSnapshotNotFound", "DBClusterSnapshotNotFoundFault", "ChangeSetNotFound", "QueueDoesNotExist",
+			"AWS.SimpleQueueService.NonExistentQueue", "NotFoundException",
+		},
+	},
+	{
+		category: FailureConditionFailed,
+		codes: []string{
+			"ConditionalCheckFailedException",
+		},
+	},
+	{
+		category: FailureUserError,
+		codes: []string{
+			"ValidationException", "ValidationError", "InvalidParameterValue", "InvalidParameterException",
+			"InvalidParameterValueException", "InvalidParameterCombination", "InvalidInputException",
+			"InvalidInput", "InvalidRequestException", "InvalidArn", "InvalidExecutionInput",
+			"InvalidDefinition", "IdempotentParameterMismatch",
+			"InvalidDBInstanceState", "InvalidDBClusterStateFault", "IncorrectInstanceState",
+		},
+	},
+}
+
+// failureStatusCodes maps the HTTP status codes returned by AWS services to
+// failure categories.
+var failureStatusCodes = []struct {
+	category FailureCategory
+	code     string
+}{
+	{FailureThrottled, "status code: 429"},
+	{FailurePermissionDenied, "status code: 403"},
+	{FailureResourceMissing, "status code: 404"},
+	{FailureUserError, "status code: 400"},
+}
+
+// classifyFailure returns the failure category of the response. The category
+// set by the service takes precedence. Otherwise, the responses with FAILED
+// status are failures of remote jobs, and the errors are classified by the
+// error codes returned by AWS services.
+func classifyFailure(resp *PluginResponse) FailureCategory {
+	if resp.FailureCategory != "" {
+		return resp.FailureCategory
+	}
+	if resp.Status == FAILED {
+		return FailureRemoteJobFailed
+	}
+
+	// The errors returned by AWS SDK are formatted as "Code: message", and
+	// the failed batch entries as "Code (message)".
+	msg := resp.Message
+	if resp.ExecutionError != nil {
+		msg = resp.ExecutionError.Error()
+	}
+	for _, entry := range failureErrorCodes {
+		for _, code := range entry.codes {
+			if strings.Contains(msg, code+": ") || strings.Contains(msg, code+" (") {
+				return entry.category
+			}
+		}
+	}
+	for _, entry := range failureStatusCodes {
+		if strings.Contains(msg, entry.code) {
+			return entry.category
+		}
+	}
+	return FailureInternalError
+}
+
+// getFailurePhase returns the phase of the node for the failure category.
+// The failures that may succeed when retried result in NodeFailed, which is
+// retried by Argo retryStrategy by default. The other failures result in
+// NodeError.
+func getFailurePhase(category FailureCategory) wfv1.NodePhase {
+	switch category {
+	case FailureThrottled, FailureRemoteJobFailed, FailureTimeout, FailureConditionFailed:
+		return wfv1.NodeFailed
+	}
+	return wfv1.NodeError
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	var testcases = []struct {
+		name      string
+		resp      *PluginResponse
+		want      FailureCategory
+		wantPhase wfv1.NodePhase
+	}{
+		{
+			name: "test remote job failure",
+			resp: &PluginResponse{
+				Message: "aws glue job run jr_123 is FAILED",
+				Status:  4,
+			},
+			want:      FailureRemoteJobFailed,
+			wantPhase: wfv1.NodeFailed,
+		},
+		{
+			name: "test failure category set by service",
+			resp: &PluginResponse{
+				ExecutionError:  fmt.Errorf("timed out after 60s waiting for amazon s3 objects"),
+				Status:          2,
+				FailureCategory: FailureTimeout,
+			},
+			want:      FailureTimeout,
+			wantPhase: wfv1.NodeFailed,
+		},
+		{
+			name: "test throttled request",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to start aws glue job: ThrottlingException: Rate exceeded\n\tstatus code: 400, request id: foo"),
+				Status:         2,
+			},
+			want:      FailureThrottled,
+			wantPhase: wfv1.NodeFailed,
+		},
+		{
+			name: "test conditional write failure",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to put amazon dynamodb item: ConditionalCheckFailedException: The conditional request failed\n\tstatus code: 400, request id: foo"),
+				Status:         2,
+			},
+			want:      FailureConditionFailed,
+			wantPhase: wfv1.NodeFailed,
+		},
+		{
+			name: "test access denied",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to describe aws step function: AccessDeniedException: User is not authorized\n\tstatus code: 400, request id: foo"),
+				Status:         2,
+			},
+			want:      FailurePermissionDenied,
+			wantPhase: wfv1.NodeError,
+		},
+		{
+			name: "test missing resource",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to get aws glue job: EntityNotFoundException: Job not found\n\tstatus code: 400, request id: foo"),
+				Status:         2,
+			},
+			want:      FailureResourceMissing,
+			wantPhase: wfv1.NodeError,
+		},
+		{
+			name: "test missing resource by status code",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to check amazon s3 bucket: NotFound: \n\tstatus code: 404, request id: foo"),
+				Status:         2,
+			},
+			want:      FailureResourceMissing,
+			wantPhase: wfv1.NodeError,
+		},
+		{
+			name: "test invalid parameters",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to start amazon sagemaker pipeline: ValidationException: Invalid parameter\n\tstatus code: 400, request id: foo"),
+				Status:         2,
+			},
+			want:      FailureUserError,
+			wantPhase: wfv1.NodeError,
+		},
+		{
+			name: "test failed batch entries",
+			resp: &PluginResponse{
+				Message: "failed to send 1 amazon sqs messages: 0: AccessDenied (Access to the resource is denied)",
+				Status:  2,
+			},
+			want:      FailurePermissionDenied,
+			wantPhase: wfv1.NodeError,
+		},
+		{
+			name: "test plugin error",
+			resp: &PluginResponse{
+				ExecutionError: fmt.Errorf("failed to create aws session: foo"),
+				Status:         2,
+			},
+			want:      FailureInternalError,
+			wantPhase: wfv1.NodeError,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := classifyFailure(tc.resp)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.wantPhase, getFailurePhase(got)); diff != "" {
+				t.Fatalf("test name: %s, unexpected phase (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
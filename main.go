@@ -76,4 +76,11 @@ func ConfigureFlags(cmd *cobra.Command, ex *ExecutorPlugin) {
 	}
 	flags.IntVarP(&ex.Port, "port", "", port, "listening port of HTTP server")
 	flags.Bool("debug", false, "enable debug level logging")
+	flags.StringVarP(&ex.OTLPEndpoint, "otlp-endpoint", "", ex.OTLPEndpoint, "host and port of OTLP/HTTP collector receiving traces, e.g. otel-collector:4318")
+	flags.BoolVarP(&ex.OTLPInsecure, "otlp-insecure", "", ex.OTLPInsecure, "export traces to OTLP/HTTP collector without TLS")
+	flags.StringVarP(&ex.TokenFile, "token-file", "", ex.TokenFile, "path to the bearer token authenticating template.execute requests, default "+defaultTokenFile)
+	flags.BoolVarP(&ex.AuthDisabled, "disable-auth", "", ex.AuthDisabled, "disable the authentication of template.execute requests, e.g. for local development")
+	flags.StringVarP(&ex.TLSCertFile, "tls-cert-file", "", ex.TLSCertFile, "path to the TLS certificate of HTTPS server, reloaded on change")
+	flags.StringVarP(&ex.TLSKeyFile, "tls-key-file", "", ex.TLSKeyFile, "path to the TLS private key of HTTPS server, reloaded on change")
+	flags.StringVarP(&ex.TLSClientCAFile, "tls-client-ca-file", "", ex.TLSClientCAFile, "path to the CA certificates verifying client certificates, requires client certificates for template.execute requests when set")
 }
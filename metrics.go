@@ -0,0 +1,175 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const metricsNamespace = "awf_aws_plugin"
+
+var (
+	// metricRequests counts template.execute requests.
+	metricRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of template.execute requests by service, action and outcome.",
+	}, []string{"service", "action", "outcome"})
+
+	// metricAWSAPICallDuration measures the latency of AWS API calls,
+	// including retries.
+	metricAWSAPICallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "aws_api_call_duration_seconds",
+		Help:      "Latency of AWS API calls by service and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "operation"})
+
+	// metricAWSAPIErrors counts failed AWS API calls.
+	metricAWSAPIErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "aws_api_errors_total",
+		Help:      "Number of failed AWS API calls by service, operation and error code.",
+	}, []string{"service", "operation", "code"})
+
+	// metricTrackedExecutions is the number of executions tracked by the
+	// plugin.
+	metricTrackedExecutions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "tracked_executions",
+		Help:      "Number of executions tracked by the plugin by service and status.",
+	}, []string{"service", "status"})
+
+	// metricRunDuration measures the duration of remote runs, from their
+	// start until the plugin observes their completion.
+	metricRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "run_duration_seconds",
+		Help:      "Duration of remote runs by service and status.",
+		Buckets:   prometheus.ExponentialBuckets(10, 2, 12),
+	}, []string{"service", "status"})
+)
+
+// awsAPIMetricsHandler records the latency and errors of AWS API calls.
+var awsAPIMetricsHandler = request.NamedHandler{
+	Name: "awf.AWSAPIMetricsHandler",
+	Fn: func(r *request.Request) {
+		service := r.ClientInfo.ServiceName
+		operation := r.Operation.Name
+		metricAWSAPICallDuration.WithLabelValues(service, operation).Observe(time.Since(r.Time).Seconds())
+		if r.Error != nil {
+			code := "unknown"
+			if err, ok := r.Error.(awserr.Error); ok {
+				code = err.Code()
+			}
+			metricAWSAPIErrors.WithLabelValues(service, operation, code).Inc()
+		}
+	},
+}
+
+// trackedExecution is the execution tracked by the plugin.
+type trackedExecution struct {
+	service   string
+	status    string
+	startedAt time.Time
+	// span is the long-lived span of the execution, which ends when the
+	// execution completes.
+	span trace.Span
+}
+
+// executionTracker tracks the statuses of executions for the metrics and
+// traces.
+type executionTracker struct {
+	sync.Mutex
+	executions map[string]*trackedExecution
+}
+
+// executions tracks the executions started by the plugin.
+var executions = &executionTracker{
+	executions: make(map[string]*trackedExecution),
+}
+
+// update records the status of the execution. It measures the duration of
+// the run and ends the span of the execution when the execution completes.
+// It stops tracking the execution once the execution completes or the plugin
+// no longer tracks it.
+func (t *executionTracker) update(ctx context.Context, id, nodeID, service string, resp *PluginResponse, tracked bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	key := workflowKey(id, nodeID)
+	status := resp.Status
+	e, exists := t.executions[key]
+	switch {
+	case exists:
+		metricTrackedExecutions.WithLabelValues(e.service, e.status).Dec()
+	case tracked:
+		e = &trackedExecution{
+			service:   service,
+			startedAt: time.Now(),
+		}
+		_, e.span = tracer().Start(context.Background(), "aws.execution",
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.LinkFromContext(ctx)),
+			trace.WithAttributes(
+				attribute.String("awf.workflow.uid", id),
+				attribute.String("awf.node.id", nodeID),
+				attribute.String("awf.service", service),
+			),
+		)
+		t.executions[key] = e
+	default:
+		return
+	}
+
+	// The span of the execution is active until the execution completes.
+	if !exists || e.status == RUNNING.String() {
+		e.span.AddEvent("status", trace.WithAttributes(
+			attribute.String("awf.status", status.String()),
+			attribute.String("awf.message", resp.Message),
+		))
+		if status != RUNNING {
+			if exists {
+				metricRunDuration.WithLabelValues(e.service, status.String()).Observe(time.Since(e.startedAt).Seconds())
+			}
+			if status != SUCCESS {
+				e.span.SetStatus(codes.Error, resp.Message)
+			}
+			e.span.End()
+		}
+	}
+
+	// Correlate the span of the request with the span of the execution.
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("awf.execution.trace_id", e.span.SpanContext().TraceID().String()),
+	)
+
+	if !tracked || status != RUNNING {
+		delete(t.executions, key)
+		return
+	}
+	e.status = status.String()
+	metricTrackedExecutions.WithLabelValues(e.service, e.status).Inc()
+}
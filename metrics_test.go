@@ -0,0 +1,93 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExecutionTracker(t *testing.T) {
+	tracker := &executionTracker{
+		executions: make(map[string]*trackedExecution),
+	}
+
+	getGauges := func() map[string]float64 {
+		return map[string]float64{
+			"running": testutil.ToFloat64(metricTrackedExecutions.WithLabelValues("test_service", "running")),
+			"success": testutil.ToFloat64(metricTrackedExecutions.WithLabelValues("test_service", "success")),
+		}
+	}
+
+	var testcases = []struct {
+		name    string
+		id      string
+		status  PluginWorkflowStatus
+		tracked bool
+		want    map[string]float64
+	}{
+		{
+			name:    "test untracked execution",
+			id:      "foo",
+			status:  SUCCESS,
+			tracked: false,
+			want:    map[string]float64{"running": 0, "success": 0},
+		},
+		{
+			name:    "test started execution",
+			id:      "foo",
+			status:  RUNNING,
+			tracked: true,
+			want:    map[string]float64{"running": 1, "success": 0},
+		},
+		{
+			name:    "test another started execution",
+			id:      "bar",
+			status:  RUNNING,
+			tracked: true,
+			want:    map[string]float64{"running": 2, "success": 0},
+		},
+		{
+			name:    "test completed execution",
+			id:      "foo",
+			status:  SUCCESS,
+			tracked: true,
+			want:    map[string]float64{"running": 1, "success": 0},
+		},
+		{
+			name:    "test execution no longer tracked by plugin",
+			id:      "bar",
+			status:  SUCCESS,
+			tracked: false,
+			want:    map[string]float64{"running": 0, "success": 0},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker.update(context.Background(), tc.id, "", "test_service", &PluginResponse{Status: tc.status}, tc.tracked)
+			if diff := cmp.Diff(tc.want, getGauges()); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+			if tc.status != RUNNING {
+				if _, exists := tracker.executions[workflowKey(tc.id, "")]; exists {
+					t.Fatalf("test name: %s, completed execution %s is still tracked", tc.name, tc.id)
+				}
+			}
+		})
+	}
+}
@@ -15,17 +15,27 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	wfclientset "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned"
 	"github.com/argoproj/argo-workflows/v3/pkg/plugins/executor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -49,13 +59,23 @@ var (
 
 // ExecutorPlugin defines plugin pattributes.
 type ExecutorPlugin struct {
-	Port         int
-	Logger       *zap.Logger
-	Mock         bool
-	ClientConfig *rest.Config
-	Client       *wfclientset.Clientset
-	DebugEnabled bool
-	Workflows    map[string]*PluginWorkflow
+	Port            int
+	Logger          *zap.Logger
+	Mock            bool
+	ClientConfig    *rest.Config
+	Client          *wfclientset.Clientset
+	DebugEnabled    bool
+	OTLPEndpoint    string
+	OTLPInsecure    bool
+	TokenFile       string
+	AuthDisabled    bool
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	Workflows       map[string]*PluginWorkflow
+	// workflowsMu guards Workflows, which the concurrent requests of the
+	// workflow nodes access.
+	workflowsMu sync.Mutex
 }
 
 // Configure parses cli arguments and configures the plugin.
@@ -78,6 +98,7 @@ func (ex *ExecutorPlugin) Configure(flags *pflag.FlagSet) error {
 		zap.String("plugin_name", app.Name),
 		zap.Int("port", ex.Port),
 		zap.String("log_level", ex.Logger.Level().CapitalString()),
+		zap.String("otlp_endpoint", ex.OTLPEndpoint),
 	)
 
 	if ex.ClientConfig == nil {
@@ -108,9 +129,42 @@ func (ex *ExecutorPlugin) Execute(c *cobra.Command, args []string) (err error) {
 		return err
 	}
 	defer ex.Logger.Sync()
-	http.HandleFunc("/api/v1/template.execute", handleTemplateExecute(ex))
+
+	shutdownTracing, err := ex.configureTracing(c.Context())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			ex.Logger.Warn("failed to stop trace export", zap.Error(err))
+		}
+	}()
+
+	tlsConfig, err := ex.configureTLS()
+	if err != nil {
+		return err
+	}
+
+	http.HandleFunc("/api/v1/template.execute", handleClientCertificate(ex, handleAuthentication(ex, handleTemplateExecute(ex))))
 	http.HandleFunc("/healthz", handleHealthCheck(ex))
-	err = http.ListenAndServe(fmt.Sprintf(":%d", ex.Port), nil)
+	http.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%d", ex.Port),
+		TLSConfig: tlsConfig,
+	}
+	if tlsConfig == nil {
+		ex.Logger.Warn("tls is not configured, serving plain http", zap.String("plugin_name", app.Name))
+		err = server.ListenAndServe()
+		return
+	}
+	ex.Logger.Info("serving https",
+		zap.String("plugin_name", app.Name),
+		zap.String("tls_cert_file", ex.TLSCertFile),
+		zap.Bool("client_cert_required", ex.TLSClientCAFile != ""),
+	)
+	// The certificate is served by tls.Config.GetCertificate.
+	err = server.ListenAndServeTLS("", "")
 	return
 }
 
@@ -132,14 +186,47 @@ func handleHealthCheck(ex *ExecutorPlugin) func(w http.ResponseWriter, req *http
 	}
 }
 
+// buildNodeOutputs converts plugin response outputs to Argo output parameters.
+func buildNodeOutputs(m map[string]string) *wfv1.Outputs {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	outputs := &wfv1.Outputs{}
+	for _, k := range keys {
+		outputs.Parameters = append(outputs.Parameters, wfv1.Parameter{
+			Name:  k,
+			Value: wfv1.AnyStringPtr(m[k]),
+		})
+	}
+	return outputs
+}
+
+// buildNodeID returns the ID of the workflow node executing the template.
+// The agent does not send the ID of the node, so the ID is derived from the
+// name of the template and the plugin input, which differs between the nodes
+// of the same template, e.g. the items of a fan-out.
+func buildNodeID(templateName string, pluginInputJSON []byte) string {
+	sum := sha256.Sum256(pluginInputJSON)
+	return templateName + "-" + hex.EncodeToString(sum[:6])
+}
+
 func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *http.Request) {
 	ex.Logger.Debug("registered template.execute handler")
 
 	return func(w http.ResponseWriter, req *http.Request) {
 		ex.Logger.Debug("received template.execute request")
+		ctx, span := tracer().Start(req.Context(), "template.execute", trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
 		resp := &PluginResponse{}
+		var pluginInput *PluginRequest
+		var wfID string
 		defer func() {
 			if resp.RequestError != nil {
+				metricRequests.WithLabelValues("", "", "bad_request").Inc()
+				span.SetStatus(codes.Error, resp.RequestError.Error())
 				w.WriteHeader(http.StatusBadRequest)
 				return
 			}
@@ -153,7 +240,8 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 					resp.Message = "success"
 				}
 			case 2:
-				phase = wfv1.NodeError
+				resp.FailureCategory = classifyFailure(resp)
+				phase = getFailurePhase(resp.FailureCategory)
 				if resp.Message == "" {
 					if resp.ExecutionError != nil {
 						resp.Message = resp.ExecutionError.Error()
@@ -161,6 +249,12 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 						resp.Message = "error"
 					}
 				}
+			case 4:
+				resp.FailureCategory = classifyFailure(resp)
+				phase = wfv1.NodeFailed
+				if resp.Message == "" {
+					resp.Message = "failed"
+				}
 			case 3:
 				phase = wfv1.NodeRunning
 				if resp.Message == "" {
@@ -183,8 +277,42 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 			}
 
 			nodeResult := &wfv1.NodeResult{
-				Phase:   phase,
-				Message: resp.Message,
+				Phase:    phase,
+				Message:  resp.Message,
+				Progress: resp.Progress,
+			}
+
+			if resp.FailureCategory != "" {
+				if resp.Outputs == nil {
+					resp.Outputs = make(map[string]string)
+				}
+				resp.Outputs["failure_category"] = string(resp.FailureCategory)
+			}
+
+			if len(resp.Outputs) > 0 {
+				nodeResult.Outputs = buildNodeOutputs(resp.Outputs)
+			}
+
+			span.SetAttributes(
+				attribute.String("awf.phase", string(phase)),
+				attribute.String("awf.failure_category", string(resp.FailureCategory)),
+			)
+			if phase == wfv1.NodeFailed || phase == wfv1.NodeError {
+				span.SetStatus(codes.Error, resp.Message)
+			}
+
+			if pluginInput != nil {
+				metricRequests.WithLabelValues(pluginInput.ServiceName, pluginInput.Action, strings.ToLower(string(phase))).Inc()
+				if !pluginInput.Mock && pluginInput.Action != "validate" {
+					// The plugin stops tracking the execution when the node
+					// completes, so that the next node of the workflow, or
+					// the retry of the node, starts new execution.
+					if resp.Status != 3 {
+						ex.deleteWorkflow(pluginInput, wfID)
+					}
+					_, tracked := ex.getWorkflow(pluginInput, wfID)
+					executions.update(ctx, wfID, pluginInput.nodeID, pluginInput.ServiceName, resp, tracked)
+				}
 			}
 
 			jsonResp, jsonErr := json.Marshal(executor.ExecuteTemplateReply{
@@ -232,7 +360,14 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 
 		ns := args.Workflow.ObjectMeta.Namespace
 		wfName := args.Workflow.ObjectMeta.Name
-		wfID := args.Workflow.ObjectMeta.Uid
+		wfID = string(args.Workflow.ObjectMeta.Uid)
+
+		span.SetAttributes(
+			attribute.String("awf.workflow.namespace", ns),
+			attribute.String("awf.workflow.name", wfName),
+			attribute.String("awf.workflow.uid", wfID),
+			attribute.String("awf.template.name", args.Template.Name),
+		)
 
 		ex.Logger.Debug("received template.execute arguments",
 			zap.String("namespace", ns),
@@ -256,7 +391,8 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 			return
 		}
 
-		pluginInput, pluginInputFound := pluginInputBody["awf-aws-plugin"]
+		var pluginInputFound bool
+		pluginInput, pluginInputFound = pluginInputBody["awf-aws-plugin"]
 		if !pluginInputFound {
 			ex.Logger.Error("plugin input not found")
 			resp.RequestError = ErrRequestInputMalformedError.WithArgs("plugin input not found")
@@ -271,10 +407,20 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 			return
 		}
 
+		pluginInput.ctx = ctx
+		pluginInput.nodeID = buildNodeID(args.Template.Name, pluginInputJSON)
+		span.SetAttributes(
+			attribute.String("awf.node.id", pluginInput.nodeID),
+			attribute.String("awf.service", pluginInput.ServiceName),
+			attribute.String("awf.action", pluginInput.Action),
+			attribute.String("awf.kind", pluginInput.Kind),
+		)
+
 		ex.Logger.Debug("plugin input arguments",
 			zap.String("action", pluginInput.Action),
 			zap.String("service", pluginInput.ServiceName),
 			zap.String("resource_arn", pluginInput.ResourceArn),
+			zap.String("node_id", pluginInput.nodeID),
 		)
 
 		if pluginInput.Mock {
@@ -300,7 +446,7 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 				resp = ex.CheckIfSageMakerPipelineExists(pluginInput)
 				return
 			case "execute":
-				pluginWorkflow, exists := ex.Workflows[wfID]
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
 				if exists {
 					resp = ex.CheckSageMakerPipelineExecution(pluginInput, pluginWorkflow.ID)
 					return
@@ -309,18 +455,49 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 				return
 			}
 		case "aws_glue":
-			switch pluginInput.Action {
-			case "validate":
-				resp = ex.CheckIfGlueJobExists(pluginInput)
-				return
-			case "execute":
-				pluginWorkflow, exists := ex.Workflows[wfID]
-				if exists {
-					resp = ex.CheckGlueJobExecution(pluginInput, pluginWorkflow.ID)
+			switch pluginInput.Kind {
+			case "crawler":
+				switch pluginInput.Action {
+				case "validate":
+					resp = ex.CheckIfGlueCrawlerExists(pluginInput)
+					return
+				case "execute":
+					pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+					if exists {
+						resp = ex.CheckGlueCrawlerExecution(pluginInput, pluginWorkflow)
+						return
+					}
+					resp = ex.StartGlueCrawlerExecution(pluginInput, wfID)
+					return
+				}
+			case "workflow":
+				switch pluginInput.Action {
+				case "validate":
+					resp = ex.CheckIfGlueWorkflowExists(pluginInput)
+					return
+				case "execute":
+					pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+					if exists {
+						resp = ex.CheckGlueWorkflowExecution(pluginInput, pluginWorkflow.ID)
+						return
+					}
+					resp = ex.StartGlueWorkflowExecution(pluginInput, wfID)
+					return
+				}
+			default:
+				switch pluginInput.Action {
+				case "validate":
+					resp = ex.CheckIfGlueJobExists(pluginInput)
+					return
+				case "execute":
+					pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+					if exists {
+						resp = ex.CheckGlueJobExecution(pluginInput, pluginWorkflow.ID)
+						return
+					}
+					resp = ex.StartGlueJobExecution(pluginInput, wfID)
 					return
 				}
-				resp = ex.StartGlueJobExecution(pluginInput, wfID)
-				return
 			}
 		case "aws_step_functions":
 			switch pluginInput.Action {
@@ -328,9 +505,9 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 				resp = ex.CheckIfStepFunctionExists(pluginInput)
 				return
 			case "execute":
-				pluginWorkflow, exists := ex.Workflows[wfID]
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
 				if exists {
-					resp = ex.CheckStepFunctionExecution(pluginInput, pluginWorkflow.ID)
+					resp = ex.CheckStepFunctionExecution(pluginInput, pluginWorkflow)
 					return
 				}
 				resp = ex.StartStepFunctionExecution(pluginInput, wfID)
@@ -343,7 +520,7 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 				resp = ex.CheckIfLambdaFunctionExists(pluginInput)
 				return
 			case "execute":
-				pluginWorkflow, exists := ex.Workflows[wfID]
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
 				if exists {
 					resp = ex.CheckLambdaFunctionExecution(pluginInput, pluginWorkflow)
 					return
@@ -351,6 +528,181 @@ func handleTemplateExecute(ex *ExecutorPlugin) func(w http.ResponseWriter, req *
 				resp = ex.StartLambdaFunctionExecution(pluginInput, wfID)
 				return
 			}
+		case "amazon_redshift_data":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfRedshiftDatabaseExists(pluginInput)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				if exists {
+					resp = ex.CheckRedshiftStatementExecution(pluginInput, pluginWorkflow.ID)
+					return
+				}
+				resp = ex.StartRedshiftStatementExecution(pluginInput, wfID)
+				return
+			}
+		case "amazon_sagemaker_training", "amazon_sagemaker_processing", "amazon_sagemaker_transform":
+			kind := sageMakerJobKinds[pluginInput.ServiceName]
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.ValidateSageMakerJob(pluginInput, kind, wfID)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				if exists {
+					resp = ex.CheckSageMakerJobExecution(pluginInput, kind, pluginWorkflow.ID)
+					return
+				}
+				resp = ex.StartSageMakerJobExecution(pluginInput, kind, wfID)
+				return
+			}
+		case "aws_codebuild":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfCodeBuildProjectExists(pluginInput)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				if exists {
+					resp = ex.CheckCodeBuildExecution(pluginInput, pluginWorkflow.ID)
+					return
+				}
+				resp = ex.StartCodeBuildExecution(pluginInput, wfID)
+				return
+			}
+		case "amazon_sqs":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfSQSQueueExists(pluginInput)
+				return
+			case "send":
+				resp = ex.SendSQSMessages(pluginInput, wfID)
+				return
+			case "await":
+				resp = ex.AwaitSQSMessage(pluginInput, wfID)
+				return
+			}
+		case "amazon_sns":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfSNSTopicExists(pluginInput)
+				return
+			case "execute":
+				resp = ex.PublishSNSMessage(pluginInput, wfID)
+				return
+			}
+		case "amazon_eventbridge":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfEventBusExists(pluginInput)
+				return
+			case "execute":
+				resp = ex.PutEventBridgeEvents(pluginInput)
+				return
+			}
+		case "amazon_s3":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfS3BucketExists(pluginInput)
+				return
+			case "wait":
+				resp = ex.WaitForS3Objects(pluginInput, wfID)
+				return
+			case "copy":
+				resp = ex.CopyS3Objects(pluginInput, wfID)
+				return
+			case "delete":
+				resp = ex.DeleteS3Objects(pluginInput, wfID)
+				return
+			case "tag":
+				resp = ex.TagS3Objects(pluginInput, wfID)
+				return
+			}
+		case "amazon_dynamodb":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfDynamoDBTableExists(pluginInput)
+				return
+			case "put":
+				resp = ex.PutDynamoDBItem(pluginInput)
+				return
+			case "update":
+				resp = ex.UpdateDynamoDBItem(pluginInput)
+				return
+			case "get":
+				resp = ex.GetDynamoDBItem(pluginInput)
+				return
+			case "wait":
+				resp = ex.WaitForDynamoDBItem(pluginInput, wfID)
+				return
+			}
+		case "aws_ssm":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfSSMDocumentExists(pluginInput)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				switch pluginInput.Kind {
+				case "automation":
+					if exists {
+						resp = ex.CheckSSMAutomationExecution(pluginInput, pluginWorkflow.ID)
+						return
+					}
+					resp = ex.StartSSMAutomationExecution(pluginInput, wfID)
+					return
+				default:
+					if exists {
+						resp = ex.CheckSSMCommandExecution(pluginInput, pluginWorkflow.ID)
+						return
+					}
+					resp = ex.StartSSMCommandExecution(pluginInput, wfID)
+					return
+				}
+			}
+		case "aws_cloudformation":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfCloudFormationTemplateIsValid(pluginInput)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				if exists {
+					resp = ex.CheckCloudFormationStackDeployment(pluginInput, pluginWorkflow)
+					return
+				}
+				resp = ex.StartCloudFormationStackDeployment(pluginInput, wfID)
+				return
+			}
+		case "amazon_ec2":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfEC2InstancesExist(pluginInput)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				if exists {
+					resp = ex.CheckEC2InstancesOperation(pluginInput, pluginWorkflow.ID)
+					return
+				}
+				resp = ex.StartEC2InstancesOperation(pluginInput, wfID)
+				return
+			}
+		case "amazon_rds":
+			switch pluginInput.Action {
+			case "validate":
+				resp = ex.CheckIfRDSResourceExists(pluginInput)
+				return
+			case "execute":
+				pluginWorkflow, exists := ex.getWorkflow(pluginInput, wfID)
+				if exists {
+					resp = ex.CheckRDSOperation(pluginInput, pluginWorkflow.ID)
+					return
+				}
+				resp = ex.StartRDSOperation(pluginInput, wfID)
+				return
+			}
 		default:
 			ex.Logger.Error("encountered error during validation of plugin request", zap.String("error", "unsupported service name"))
 			resp.RequestError = ErrRequestInputMalformedError.WithArgs("unsupported service name")
@@ -142,6 +142,64 @@ func newTestHTTPRequest(t *testing.T, testName string, url string, req *testHTTP
 	return r
 }
 
+// newTestTemplateExecuteRequest returns the template.execute request of the
+// mock workflow for the template. The plugin input overrides the default
+// input, which runs the template in the mock mode.
+func newTestTemplateExecuteRequest(templateName string, input map[string]interface{}) *testHTTPRequest {
+	pluginInput := map[string]interface{}{
+		"account_id":  "100000000002",
+		"region_name": "us-west-2",
+		"mock":        true,
+		"mock_state":  "success",
+	}
+	for k, v := range input {
+		pluginInput[k] = v
+	}
+
+	return &testHTTPRequest{
+		method: "POST",
+		headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		path: "/api/v1/template.execute",
+		data: map[string]interface{}{
+			"workflow": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":      "awf-test-r58tg",
+					"namespace": "argo",
+					"uid":       "27c01e7c-9d93-450f-a001-c64d649aac99",
+				},
+			},
+			"template": map[string]interface{}{
+				"name":     templateName,
+				"inputs":   map[string]interface{}{},
+				"outputs":  map[string]interface{}{},
+				"metadata": map[string]interface{}{},
+				"plugin": map[string]interface{}{
+					"awf-aws-plugin": pluginInput,
+				},
+			},
+		},
+	}
+}
+
+// newTestNodeResponse returns the expected response of template.execute
+// request with the node in the phase. The running nodes are requeued.
+func newTestNodeResponse(phase, message string) map[string]interface{} {
+	resp := map[string]interface{}{
+		"content_type": "text/plain; charset=utf-8",
+		"status_code":  200,
+		"node": map[string]interface{}{
+			"message": message,
+			"phase":   phase,
+		},
+	}
+	if phase == "Running" {
+		resp["requeue"] = "1m0s"
+	}
+	return resp
+}
+
 func TestExecutorPlugin(t *testing.T) {
 	log := NewLogger(zapcore.DebugLevel)
 	defer log.Sync()
@@ -212,6 +270,7 @@ func TestExecutorPlugin(t *testing.T) {
 
 	tokenFilePath := tmpDir + "/token"
 	token := "foo"
+	writeTestTokenFile(t, tokenFilePath, token)
 
 	config := &rest.Config{
 		Host: "https://" + net.JoinHostPort(kubeHost, kubePort),
@@ -233,6 +292,7 @@ func TestExecutorPlugin(t *testing.T) {
 		ClientConfig: config,
 		Client:       client,
 		DebugEnabled: true,
+		TokenFile:    tokenFilePath,
 	}
 
 	cmd := BuildCommand(ex)
@@ -245,6 +305,16 @@ func TestExecutorPlugin(t *testing.T) {
 
 	go startPlugin()
 
+	// Wait for the plugin to start accepting connections.
+	for i := 0; i < 50; i++ {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", ex.Port), 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
 	var testcases = []struct {
 		name      string
 		req       *testHTTPRequest
@@ -253,362 +323,481 @@ func TestExecutorPlugin(t *testing.T) {
 		data      map[string]interface{}
 		shouldErr bool
 		err       error
+		anonymous bool
 		want      map[string]interface{}
 	}{
 		{
-			name: "test validate amazon sagemaker pipeline",
+			name:      "test template.execute without bearer token",
+			anonymous: true,
 			req: &testHTTPRequest{
 				method: "POST",
 				headers: map[string]string{
 					"Content-Type": "application/json",
 				},
 				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "sm-pipelines-r58tg",
-							"namespace": "argo",
-							"uid":       "27c01e7c-9d93-450f-a001-c64d649aac99",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "validate_pipeline",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":    "100000000002",
-								"action":        "validate",
-								"service":       "amazon_sagemaker_pipelines",
-								"pipeline_name": "MyPipeline",
-								"region_name":   "us-west-2",
-								"mock":          true,
-								"mock_state":    "success",
-							},
-						},
-					},
-				},
+				data: map[string]interface{}{},
 			},
 			want: map[string]interface{}{
+				"status_code":  401,
 				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "success",
-					"phase":   "Succeeded",
-				},
 			},
 		},
 		{
-			name: "test execute amazon sagemaker pipeline",
+			name: "test template.execute with invalid bearer token",
 			req: &testHTTPRequest{
 				method: "POST",
 				headers: map[string]string{
-					"Content-Type": "application/json",
+					"Content-Type":  "application/json",
+					"Authorization": "Bearer bar",
 				},
 				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "sm-pipelines-b863f",
-							"namespace": "argo",
-							"uid":       "1018894b-ede2-4b38-b258-e707e133b839",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "execute_pipeline",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":    "100000000002",
-								"action":        "execute",
-								"service":       "amazon_sagemaker_pipelines",
-								"pipeline_name": "MyPipeline",
-								"region_name":   "us-west-2",
-								"mock":          true,
-								"mock_state":    "running",
-							},
-						},
-					},
-				},
+				data: map[string]interface{}{},
 			},
 			want: map[string]interface{}{
+				"status_code":  403,
 				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "running",
-					"phase":   "Running",
-				},
-				"requeue": "1m0s",
 			},
 		},
+		{
+			name: "test validate amazon sagemaker pipeline",
+			req: newTestTemplateExecuteRequest("validate_pipeline", map[string]interface{}{
+				"action":        "validate",
+				"service":       "amazon_sagemaker_pipelines",
+				"pipeline_name": "MyPipeline",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute amazon sagemaker pipeline",
+			req: newTestTemplateExecuteRequest("execute_pipeline", map[string]interface{}{
+				"action":        "execute",
+				"service":       "amazon_sagemaker_pipelines",
+				"pipeline_name": "MyPipeline",
+				"mock_state":    "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
 		{
 			name: "test validate aws glue job",
-			req: &testHTTPRequest{
-				method: "POST",
-				headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "aws-glue-job-t7c34",
-							"namespace": "argo",
-							"uid":       "ff4d1c6b-7c7d-46ad-b36e-18afd3b6d3cb",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "validate_glue_job",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":  "100000000002",
-								"action":      "validate",
-								"service":     "aws_glue",
-								"job_name":    "MyGlueJob",
-								"region_name": "us-west-2",
-								"mock":        true,
-								"mock_state":  "success",
-							},
-						},
-					},
+			req: newTestTemplateExecuteRequest("validate_glue_job", map[string]interface{}{
+				"action":   "validate",
+				"service":  "aws_glue",
+				"job_name": "MyGlueJob",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute aws glue job",
+			req: newTestTemplateExecuteRequest("execute_glue_job", map[string]interface{}{
+				"action":     "execute",
+				"service":    "aws_glue",
+				"job_name":   "MyGlueJob",
+				"mock_state": "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test validate aws step function",
+			req: newTestTemplateExecuteRequest("validate_step_function", map[string]interface{}{
+				"action":             "validate",
+				"service":            "aws_step_functions",
+				"step_function_name": "MyStepFunction",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute aws step function",
+			req: newTestTemplateExecuteRequest("execute_step_function", map[string]interface{}{
+				"action":             "execute",
+				"service":            "aws_step_functions",
+				"step_function_name": "MyStepFunction",
+				"mock_state":         "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test validate aws lambda function",
+			req: newTestTemplateExecuteRequest("validate_lambda_function", map[string]interface{}{
+				"action":               "validate",
+				"service":              "aws_lambda",
+				"lambda_function_name": "MyLambdaFunction",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute aws lambda function",
+			req: newTestTemplateExecuteRequest("execute_lambda_function", map[string]interface{}{
+				"action":               "execute",
+				"service":              "aws_lambda",
+				"lambda_function_name": "MyLambdaFunction",
+				"mock_state":           "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test validate amazon redshift database",
+			req: newTestTemplateExecuteRequest("validate_redshift_database", map[string]interface{}{
+				"action":         "validate",
+				"service":        "amazon_redshift_data",
+				"workgroup_name": "MyWorkgroup",
+				"database_name":  "dev",
+				"secret_arn":     "arn:aws:secretsmanager:us-west-2:100000000002:secret:MyRedshiftSecret",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute amazon redshift statement without sql",
+			req: newTestTemplateExecuteRequest("execute_redshift_statement", map[string]interface{}{
+				"action":             "execute",
+				"service":            "amazon_redshift_data",
+				"cluster_identifier": "my-cluster",
+				"database_name":      "dev",
+				"database_user":      "awsuser",
+				"mock_state":         "running",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
+			},
+		},
+		{
+			name: "test validate amazon sagemaker training job",
+			req: newTestTemplateExecuteRequest("validate_training_job", map[string]interface{}{
+				"action":   "validate",
+				"service":  "amazon_sagemaker_training",
+				"job_name": "MyTrainingJob",
+				"job_spec": map[string]interface{}{
+					"RoleArn": "arn:aws:iam::100000000002:role/SageMakerRole",
 				},
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute amazon sagemaker transform job without job spec",
+			req: newTestTemplateExecuteRequest("execute_transform_job", map[string]interface{}{
+				"action":     "execute",
+				"service":    "amazon_sagemaker_transform",
+				"job_name":   "MyTransformJob",
+				"mock_state": "running",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
 			},
+		},
+		{
+			name: "test validate aws glue crawler",
+			req: newTestTemplateExecuteRequest("validate_glue_crawler", map[string]interface{}{
+				"action":       "validate",
+				"service":      "aws_glue",
+				"kind":         "crawler",
+				"crawler_name": "MyGlueCrawler",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test execute aws glue workflow",
+			req: newTestTemplateExecuteRequest("execute_glue_workflow", map[string]interface{}{
+				"action":             "execute",
+				"service":            "aws_glue",
+				"kind":               "workflow",
+				"glue_workflow_name": "MyGlueWorkflow",
+				"mock_state":         "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test execute aws glue with unsupported kind",
+			req: newTestTemplateExecuteRequest("execute_glue_trigger", map[string]interface{}{
+				"action":     "execute",
+				"service":    "aws_glue",
+				"kind":       "trigger",
+				"mock_state": "running",
+			}),
 			want: map[string]interface{}{
-				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "success",
-					"phase":   "Succeeded",
-				},
+				"status_code": 400,
 			},
 		},
 		{
-			name: "test execute aws glue job",
-			req: &testHTTPRequest{
-				method: "POST",
-				headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "aws-glue-job-t7c34",
-							"namespace": "argo",
-							"uid":       "c4525afe-971d-491c-bc95-9624268119c3",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "execute_glue_job",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":  "100000000002",
-								"action":      "execute",
-								"service":     "aws_glue",
-								"job_name":    "MyGlueJob",
-								"region_name": "us-west-2",
-								"mock":        true,
-								"mock_state":  "running",
-							},
-						},
-					},
+			name: "test execute aws glue job with run options",
+			req: newTestTemplateExecuteRequest("execute_glue_job", map[string]interface{}{
+				"action":              "execute",
+				"service":             "aws_glue",
+				"job_name":            "MyGlueJob",
+				"worker_type":         "G.1X",
+				"number_of_workers":   10,
+				"job_timeout":         120,
+				"execution_class":     "FLEX",
+				"notify_delay_after":  30,
+				"job_bookmark_option": "pause",
+				"job_bookmark_from":   "jr_0a1b",
+				"job_bookmark_to":     "jr_2c3d",
+				"mock_state":          "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test execute aws glue job with worker type without number of workers",
+			req: newTestTemplateExecuteRequest("execute_glue_job", map[string]interface{}{
+				"action":      "execute",
+				"service":     "aws_glue",
+				"job_name":    "MyGlueJob",
+				"worker_type": "G.2X",
+				"mock_state":  "running",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
+			},
+		},
+		{
+			name: "test execute aws codebuild build",
+			req: newTestTemplateExecuteRequest("execute_codebuild", map[string]interface{}{
+				"action":         "execute",
+				"service":        "aws_codebuild",
+				"project_name":   "MyProject",
+				"source_version": "refs/heads/main",
+				"environment_variables": map[string]interface{}{
+					"STAGE": "dev",
 				},
+				"mock_state": "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test validate aws codebuild project without project name",
+			req: newTestTemplateExecuteRequest("validate_codebuild", map[string]interface{}{
+				"action":  "validate",
+				"service": "aws_codebuild",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
 			},
+		},
+		{
+			name: "test send amazon sqs message",
+			req: newTestTemplateExecuteRequest("send_sqs_message", map[string]interface{}{
+				"action":           "send",
+				"service":          "amazon_sqs",
+				"queue_name":       "requests.fifo",
+				"message_body":     "{\"task\": \"refresh\"}",
+				"message_group_id": "refresh",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test await amazon sqs message",
+			req: newTestTemplateExecuteRequest("await_sqs_message", map[string]interface{}{
+				"action":           "await",
+				"service":          "amazon_sqs",
+				"reply_queue_name": "replies",
+				"wait_timeout":     3600,
+				"mock_state":       "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test send amazon sqs message to fifo queue without group id",
+			req: newTestTemplateExecuteRequest("send_sqs_message", map[string]interface{}{
+				"action":       "send",
+				"service":      "amazon_sqs",
+				"queue_name":   "requests.fifo",
+				"message_body": "refresh",
+			}),
 			want: map[string]interface{}{
-				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "running",
-					"phase":   "Running",
-				},
-				"requeue": "1m0s",
+				"status_code": 400,
 			},
 		},
 		{
-			name: "test validate aws step function",
-			req: &testHTTPRequest{
-				method: "POST",
-				headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "aws-step-function-w62b2",
-							"namespace": "argo",
-							"uid":       "b4fbe449-3b30-41ab-8b3d-0bdc727d3b64",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "validate_step_function",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":         "100000000002",
-								"action":             "validate",
-								"service":            "aws_step_functions",
-								"step_function_name": "MyStepFunction",
-								"region_name":        "us-west-2",
-								"mock":               true,
-								"mock_state":         "success",
-							},
-						},
-					},
-				},
+			name: "test execute amazon sagemaker pipeline with unsupported action",
+			req: newTestTemplateExecuteRequest("execute_pipeline", map[string]interface{}{
+				"action":        "send",
+				"service":       "amazon_sagemaker_pipelines",
+				"pipeline_name": "MyPipeline",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
 			},
+		},
+		{
+			name: "test publish amazon sns message",
+			req: newTestTemplateExecuteRequest("publish_sns_message", map[string]interface{}{
+				"action":       "execute",
+				"service":      "amazon_sns",
+				"topic_name":   "MyTopic",
+				"subject":      "pipeline finished",
+				"message_body": "done",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test put amazon eventbridge events without source",
+			req: newTestTemplateExecuteRequest("put_events", map[string]interface{}{
+				"action":            "execute",
+				"service":           "amazon_eventbridge",
+				"event_detail_type": "PipelineFinished",
+			}),
 			want: map[string]interface{}{
-				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "success",
-					"phase":   "Succeeded",
-				},
+				"status_code": 400,
 			},
 		},
 		{
-			name: "test execute aws step function",
-			req: &testHTTPRequest{
-				method: "POST",
-				headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "aws-step-function-w62b2",
-							"namespace": "argo",
-							"uid":       "0c3eda84-d431-4b9e-8ef4-71f17d8678ee",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "execute_step_function",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":         "100000000002",
-								"action":             "execute",
-								"service":            "aws_step_functions",
-								"step_function_name": "MyStepFunction",
-								"region_name":        "us-west-2",
-								"mock":               true,
-								"mock_state":         "running",
-							},
-						},
-					},
-				},
+			name: "test wait for amazon s3 objects",
+			req: newTestTemplateExecuteRequest("wait_for_s3_objects", map[string]interface{}{
+				"action":           "wait",
+				"service":          "amazon_s3",
+				"bucket_name":      "my-bucket",
+				"object_prefix":    "incoming/2023-10-01/",
+				"min_object_count": 3,
+				"mock_state":       "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test wait for amazon s3 objects with invalid modified_after",
+			req: newTestTemplateExecuteRequest("wait_for_s3_objects", map[string]interface{}{
+				"action":         "wait",
+				"service":        "amazon_s3",
+				"bucket_name":    "my-bucket",
+				"object_key":     "incoming/_SUCCESS",
+				"modified_after": "yesterday",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
 			},
+		},
+		{
+			name: "test copy amazon s3 objects",
+			req: newTestTemplateExecuteRequest("copy_s3_objects", map[string]interface{}{
+				"action":                    "copy",
+				"service":                   "amazon_s3",
+				"bucket_name":               "my-bucket",
+				"object_prefix":             "staging/",
+				"destination_bucket_name":   "my-archive",
+				"destination_object_prefix": "archive/2023-10-01/",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test tag amazon s3 objects without tags",
+			req: newTestTemplateExecuteRequest("tag_s3_objects", map[string]interface{}{
+				"action":        "tag",
+				"service":       "amazon_s3",
+				"bucket_name":   "my-bucket",
+				"object_prefix": "staging/",
+			}),
 			want: map[string]interface{}{
-				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "running",
-					"phase":   "Running",
-				},
-				"requeue": "1m0s",
+				"status_code": 400,
 			},
 		},
 		{
-			name: "test validate aws lambda function",
-			req: &testHTTPRequest{
-				method: "POST",
-				headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "aws-lambda-function-d42f4",
-							"namespace": "argo",
-							"uid":       "3d8bc315-e477-4215-8da4-18a4a649d30b",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "validate_lambda_function",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":           "100000000002",
-								"action":               "validate",
-								"service":              "aws_lambda",
-								"lambda_function_name": "MyLambdaFunction",
-								"region_name":          "us-west-2",
-								"mock":                 true,
-								"mock_state":           "success",
-							},
-						},
-					},
-				},
+			name: "test update amazon dynamodb item",
+			req: newTestTemplateExecuteRequest("update_dynamodb_item", map[string]interface{}{
+				"action":                      "update",
+				"service":                     "amazon_dynamodb",
+				"table_name":                  "pipeline_runs",
+				"item_key":                    map[string]interface{}{"run_id": "2023-10-01"},
+				"update_expression":           "SET #s = :s",
+				"condition_expression":        "attribute_exists(run_id)",
+				"expression_attribute_names":  map[string]interface{}{"#s": "status"},
+				"expression_attribute_values": map[string]interface{}{":s": "DONE"},
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test wait for amazon dynamodb item without attribute name",
+			req: newTestTemplateExecuteRequest("wait_for_dynamodb_item", map[string]interface{}{
+				"action":     "wait",
+				"service":    "amazon_dynamodb",
+				"table_name": "pipeline_runs",
+				"item_key":   map[string]interface{}{"run_id": "2023-10-01"},
+				"mock_state": "running",
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
 			},
+		},
+		{
+			name: "test run aws ssm command",
+			req: newTestTemplateExecuteRequest("run_ssm_command", map[string]interface{}{
+				"action":        "execute",
+				"service":       "aws_ssm",
+				"document_name": "AWS-RunShellScript",
+				"target_tags":   map[string]interface{}{"Environment": "dev"},
+				"parameters":    map[string]interface{}{"commands": []interface{}{"uptime"}},
+				"mock_state":    "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test run aws ssm automation with instance ids",
+			req: newTestTemplateExecuteRequest("run_ssm_automation", map[string]interface{}{
+				"kind":          "automation",
+				"action":        "execute",
+				"service":       "aws_ssm",
+				"document_name": "AWS-RestartEC2Instance",
+				"instance_ids":  []interface{}{"i-0123456789abcdef0"},
+			}),
 			want: map[string]interface{}{
-				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "success",
-					"phase":   "Succeeded",
-				},
+				"status_code": 400,
 			},
 		},
 		{
-			name: "test execute aws lambda function",
-			req: &testHTTPRequest{
-				method: "POST",
-				headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				path: "/api/v1/template.execute",
-				data: map[string]interface{}{
-					"workflow": map[string]interface{}{
-						"metadata": map[string]interface{}{
-							"name":      "aws-lambda-function-d42f4",
-							"namespace": "argo",
-							"uid":       "dd5282c5-7703-49dc-b2a4-878ec2df2c1b",
-						},
-					},
-					"template": map[string]interface{}{
-						"name":     "execute_lambda_function",
-						"inputs":   map[string]interface{}{},
-						"outputs":  map[string]interface{}{},
-						"metadata": map[string]interface{}{},
-						"plugin": map[string]interface{}{
-							"awf-aws-plugin": map[string]interface{}{
-								"account_id":           "100000000002",
-								"action":               "execute",
-								"service":              "aws_lambda",
-								"lambda_function_name": "MyLambdaFunction",
-								"region_name":          "us-west-2",
-								"mock":                 true,
-								"mock_state":           "running",
-							},
-						},
-					},
-				},
+			name: "test deploy aws cloudformation stack",
+			req: newTestTemplateExecuteRequest("deploy_stack", map[string]interface{}{
+				"action":       "execute",
+				"service":      "aws_cloudformation",
+				"stack_name":   "data-pipeline",
+				"template_url": "https://my-bucket.s3.amazonaws.com/templates/data-pipeline.yaml",
+				"capabilities": []interface{}{"CAPABILITY_NAMED_IAM"},
+				"parameters":   map[string]interface{}{"Environment": "dev"},
+				"mock_state":   "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test deploy aws cloudformation stack with unsupported capability",
+			req: newTestTemplateExecuteRequest("deploy_stack", map[string]interface{}{
+				"action":        "execute",
+				"service":       "aws_cloudformation",
+				"stack_name":    "data-pipeline",
+				"template_body": "Resources: {}",
+				"capabilities":  []interface{}{"CAPABILITY_ADMIN"},
+			}),
+			want: map[string]interface{}{
+				"status_code": 400,
 			},
+		},
+		{
+			name: "test stop amazon ec2 instances by tags",
+			req: newTestTemplateExecuteRequest("stop_instances", map[string]interface{}{
+				"action":      "execute",
+				"service":     "amazon_ec2",
+				"operation":   "stop",
+				"target_tags": map[string]interface{}{"Environment": "dev"},
+				"mock_state":  "running",
+			}),
+			want: newTestNodeResponse("Running", "running"),
+		},
+		{
+			name: "test snapshot amazon rds cluster",
+			req: newTestTemplateExecuteRequest("snapshot_cluster", map[string]interface{}{
+				"kind":                "cluster",
+				"action":              "execute",
+				"service":             "amazon_rds",
+				"operation":           "snapshot",
+				"db_identifier":       "analytics",
+				"snapshot_identifier": "analytics-before-migration",
+			}),
+			want: newTestNodeResponse("Succeeded", "success"),
+		},
+		{
+			name: "test reboot amazon rds instance",
+			req: newTestTemplateExecuteRequest("reboot_instance", map[string]interface{}{
+				"action":        "execute",
+				"service":       "amazon_rds",
+				"operation":     "reboot",
+				"db_identifier": "analytics",
+			}),
 			want: map[string]interface{}{
-				"content_type": "text/plain; charset=utf-8",
-				"status_code":  200,
-				"node": map[string]interface{}{
-					"message": "running",
-					"phase":   "Running",
-				},
-				"requeue": "1m0s",
+				"status_code": 400,
 			},
 		},
 		{
@@ -634,6 +823,9 @@ func TestExecutorPlugin(t *testing.T) {
 			pluginClient := newTestPluginHTTPClient(t)
 			pluginURL := fmt.Sprintf("http://localhost:%d", ex.Port)
 			req := newTestHTTPRequest(t, tc.name, pluginURL, tc.req)
+			if req.Header.Get("Authorization") == "" && !tc.anonymous {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
 			resp, err := pluginClient.Do(req)
 			if err != nil {
 				t.Fatalf("test name %s: error=%v", tc.name, err)
@@ -669,3 +861,34 @@ func TestExecutorPlugin(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildNodeID(t *testing.T) {
+	var testcases = []struct {
+		name         string
+		templateName string
+		input        string
+		want         string
+	}{
+		{
+			name:         "test node of template",
+			templateName: "copy_s3_objects",
+			input:        `{"awf-aws-plugin":{"action":"copy","object_prefix":"staging/"}}`,
+			want:         "copy_s3_objects-af4623f9f560",
+		},
+		{
+			name:         "test node of same template with other input",
+			templateName: "copy_s3_objects",
+			input:        `{"awf-aws-plugin":{"action":"copy","object_prefix":"landing/"}}`,
+			want:         "copy_s3_objects-84e34a85e754",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := buildNodeID(tc.templateName, []byte(tc.input))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -14,40 +14,205 @@
 
 package main
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	allowedServiceNames = map[string]bool{
-		"amazon_sagemaker_pipelines": true,
-		"aws_glue":                   true,
-		"aws_step_functions":         true,
-		"aws_lambda":                 true,
+		"amazon_sagemaker_pipelines":  true,
+		"aws_glue":                    true,
+		"aws_step_functions":          true,
+		"aws_lambda":                  true,
+		"amazon_redshift_data":        true,
+		"amazon_sagemaker_training":   true,
+		"amazon_sagemaker_processing": true,
+		"amazon_sagemaker_transform":  true,
+		"aws_codebuild":               true,
+		"amazon_sqs":                  true,
+		"amazon_sns":                  true,
+		"amazon_eventbridge":          true,
+		"amazon_s3":                   true,
+		"amazon_dynamodb":             true,
+		"aws_ssm":                     true,
+		"aws_cloudformation":          true,
+		"amazon_ec2":                  true,
+		"amazon_rds":                  true,
 	}
 	allowedMockStates = map[string]bool{
 		"running": true,
 		"success": true,
 	}
+	allowedGlueKinds = map[string]bool{
+		"job":      true,
+		"crawler":  true,
+		"workflow": true,
+	}
+	allowedGlueWorkerTypes = map[string]bool{
+		"Standard": true,
+		"G.1X":     true,
+		"G.2X":     true,
+		"G.025X":   true,
+		"G.4X":     true,
+		"G.8X":     true,
+		"Z.2X":     true,
+	}
+	allowedGlueExecutionClasses = map[string]bool{
+		"STANDARD": true,
+		"FLEX":     true,
+	}
+	allowedGlueJobBookmarkOptions = map[string]bool{
+		"enable":  true,
+		"disable": true,
+		"pause":   true,
+	}
+	allowedSSMKinds = map[string]bool{
+		"command":    true,
+		"automation": true,
+	}
+	allowedCloudFormationCapabilities = map[string]bool{
+		"CAPABILITY_IAM":         true,
+		"CAPABILITY_NAMED_IAM":   true,
+		"CAPABILITY_AUTO_EXPAND": true,
+	}
+	allowedEC2Operations = map[string]bool{
+		"start":  true,
+		"stop":   true,
+		"reboot": true,
+	}
+	allowedRDSKinds = map[string]bool{
+		"instance": true,
+		"cluster":  true,
+	}
+	allowedRDSOperations = map[string]bool{
+		"start":    true,
+		"stop":     true,
+		"snapshot": true,
+	}
 	allowedActions = map[string]bool{
 		"validate": true,
 		"execute":  true,
 	}
+	allowedServiceActions = map[string]map[string]bool{
+		"amazon_dynamodb": {
+			"validate": true,
+			"put":      true,
+			"update":   true,
+			"get":      true,
+			"wait":     true,
+		},
+		"amazon_s3": {
+			"validate": true,
+			"wait":     true,
+			"copy":     true,
+			"delete":   true,
+			"tag":      true,
+		},
+		"amazon_sqs": {
+			"validate": true,
+			"send":     true,
+			"await":    true,
+		},
+	}
 )
 
 // PluginRequest represent Plugin input arguments.
 type PluginRequest struct {
-	Kind               string                 `json:"kind,omitempty" xml:"kind,omitempty" yaml:"kind,omitempty"`
-	AccountID          string                 `json:"account_id,omitempty" xml:"account_id,omitempty" yaml:"account_id,omitempty"`
-	ServiceName        string                 `json:"service,omitempty" xml:"service,omitempty" yaml:"service,omitempty"`
-	Action             string                 `json:"action,omitempty" xml:"action,omitempty" yaml:"action,omitempty"`
-	PipelineName       string                 `json:"pipeline_name,omitempty" xml:"pipeline_name,omitempty" yaml:"pipeline_name,omitempty"`
-	JobName            string                 `json:"job_name,omitempty" xml:"job_name,omitempty" yaml:"job_name,omitempty"`
-	StepFunctionName   string                 `json:"step_function_name,omitempty" xml:"step_function_name,omitempty" yaml:"step_function_name,omitempty"`
-	LambdaFunctionName string                 `json:"lambda_function_name,omitempty" xml:"lambda_function_name,omitempty" yaml:"lambda_function_name,omitempty"`
-	Parameters         map[string]interface{} `json:"parameters,omitempty" xml:"parameters,omitempty" yaml:"parameters,omitempty"`
-	ResourceArn        string                 `json:"resource_arn,omitempty" xml:"resource_arn,omitempty" yaml:"resource_arn,omitempty"`
-	RegionName         string                 `json:"region_name,omitempty" xml:"region_name,omitempty" yaml:"region_name,omitempty"`
-	Mock               bool                   `json:"mock,omitempty" xml:"mock,omitempty" yaml:"mock,omitempty"`
-	MockState          string                 `json:"mock_state,omitempty" xml:"mock_state,omitempty" yaml:"mock_state,omitempty"`
+	Kind                      string                   `json:"kind,omitempty" xml:"kind,omitempty" yaml:"kind,omitempty"`
+	AccountID                 string                   `json:"account_id,omitempty" xml:"account_id,omitempty" yaml:"account_id,omitempty"`
+	ServiceName               string                   `json:"service,omitempty" xml:"service,omitempty" yaml:"service,omitempty"`
+	Action                    string                   `json:"action,omitempty" xml:"action,omitempty" yaml:"action,omitempty"`
+	PipelineName              string                   `json:"pipeline_name,omitempty" xml:"pipeline_name,omitempty" yaml:"pipeline_name,omitempty"`
+	JobName                   string                   `json:"job_name,omitempty" xml:"job_name,omitempty" yaml:"job_name,omitempty"`
+	StepFunctionName          string                   `json:"step_function_name,omitempty" xml:"step_function_name,omitempty" yaml:"step_function_name,omitempty"`
+	LambdaFunctionName        string                   `json:"lambda_function_name,omitempty" xml:"lambda_function_name,omitempty" yaml:"lambda_function_name,omitempty"`
+	Parameters                map[string]interface{}   `json:"parameters,omitempty" xml:"parameters,omitempty" yaml:"parameters,omitempty"`
+	ResourceArn               string                   `json:"resource_arn,omitempty" xml:"resource_arn,omitempty" yaml:"resource_arn,omitempty"`
+	RegionName                string                   `json:"region_name,omitempty" xml:"region_name,omitempty" yaml:"region_name,omitempty"`
+	Mock                      bool                     `json:"mock,omitempty" xml:"mock,omitempty" yaml:"mock,omitempty"`
+	MockState                 string                   `json:"mock_state,omitempty" xml:"mock_state,omitempty" yaml:"mock_state,omitempty"`
+	ClusterIdentifier         string                   `json:"cluster_identifier,omitempty" xml:"cluster_identifier,omitempty" yaml:"cluster_identifier,omitempty"`
+	WorkgroupName             string                   `json:"workgroup_name,omitempty" xml:"workgroup_name,omitempty" yaml:"workgroup_name,omitempty"`
+	DatabaseName              string                   `json:"database_name,omitempty" xml:"database_name,omitempty" yaml:"database_name,omitempty"`
+	DatabaseUser              string                   `json:"database_user,omitempty" xml:"database_user,omitempty" yaml:"database_user,omitempty"`
+	SecretArn                 string                   `json:"secret_arn,omitempty" xml:"secret_arn,omitempty" yaml:"secret_arn,omitempty"`
+	SQL                       string                   `json:"sql,omitempty" xml:"sql,omitempty" yaml:"sql,omitempty"`
+	SQLBatch                  []string                 `json:"sql_batch,omitempty" xml:"sql_batch,omitempty" yaml:"sql_batch,omitempty"`
+	JobSpec                   map[string]interface{}   `json:"job_spec,omitempty" xml:"job_spec,omitempty" yaml:"job_spec,omitempty"`
+	CrawlerName               string                   `json:"crawler_name,omitempty" xml:"crawler_name,omitempty" yaml:"crawler_name,omitempty"`
+	GlueWorkflowName          string                   `json:"glue_workflow_name,omitempty" xml:"glue_workflow_name,omitempty" yaml:"glue_workflow_name,omitempty"`
+	WorkerType                string                   `json:"worker_type,omitempty" xml:"worker_type,omitempty" yaml:"worker_type,omitempty"`
+	NumberOfWorkers           int64                    `json:"number_of_workers,omitempty" xml:"number_of_workers,omitempty" yaml:"number_of_workers,omitempty"`
+	JobTimeout                int64                    `json:"job_timeout,omitempty" xml:"job_timeout,omitempty" yaml:"job_timeout,omitempty"`
+	ExecutionClass            string                   `json:"execution_class,omitempty" xml:"execution_class,omitempty" yaml:"execution_class,omitempty"`
+	SecurityConfig            string                   `json:"security_configuration,omitempty" xml:"security_configuration,omitempty" yaml:"security_configuration,omitempty"`
+	NotifyDelayAfter          int64                    `json:"notify_delay_after,omitempty" xml:"notify_delay_after,omitempty" yaml:"notify_delay_after,omitempty"`
+	JobBookmarkOption         string                   `json:"job_bookmark_option,omitempty" xml:"job_bookmark_option,omitempty" yaml:"job_bookmark_option,omitempty"`
+	JobBookmarkFrom           string                   `json:"job_bookmark_from,omitempty" xml:"job_bookmark_from,omitempty" yaml:"job_bookmark_from,omitempty"`
+	JobBookmarkTo             string                   `json:"job_bookmark_to,omitempty" xml:"job_bookmark_to,omitempty" yaml:"job_bookmark_to,omitempty"`
+	ResetJobBookmark          bool                     `json:"reset_job_bookmark,omitempty" xml:"reset_job_bookmark,omitempty" yaml:"reset_job_bookmark,omitempty"`
+	ProjectName               string                   `json:"project_name,omitempty" xml:"project_name,omitempty" yaml:"project_name,omitempty"`
+	SourceVersion             string                   `json:"source_version,omitempty" xml:"source_version,omitempty" yaml:"source_version,omitempty"`
+	EnvironmentVars           map[string]string        `json:"environment_variables,omitempty" xml:"environment_variables,omitempty" yaml:"environment_variables,omitempty"`
+	BuildspecOverride         string                   `json:"buildspec_override,omitempty" xml:"buildspec_override,omitempty" yaml:"buildspec_override,omitempty"`
+	QueueName                 string                   `json:"queue_name,omitempty" xml:"queue_name,omitempty" yaml:"queue_name,omitempty"`
+	ReplyQueueName            string                   `json:"reply_queue_name,omitempty" xml:"reply_queue_name,omitempty" yaml:"reply_queue_name,omitempty"`
+	MessageBody               string                   `json:"message_body,omitempty" xml:"message_body,omitempty" yaml:"message_body,omitempty"`
+	MessageBatch              []string                 `json:"message_batch,omitempty" xml:"message_batch,omitempty" yaml:"message_batch,omitempty"`
+	MessageAttributes         map[string]string        `json:"message_attributes,omitempty" xml:"message_attributes,omitempty" yaml:"message_attributes,omitempty"`
+	MessageGroupID            string                   `json:"message_group_id,omitempty" xml:"message_group_id,omitempty" yaml:"message_group_id,omitempty"`
+	MessageDedupID            string                   `json:"message_deduplication_id,omitempty" xml:"message_deduplication_id,omitempty" yaml:"message_deduplication_id,omitempty"`
+	CorrelationID             string                   `json:"correlation_id,omitempty" xml:"correlation_id,omitempty" yaml:"correlation_id,omitempty"`
+	WaitTimeout               int64                    `json:"wait_timeout,omitempty" xml:"wait_timeout,omitempty" yaml:"wait_timeout,omitempty"`
+	TopicName                 string                   `json:"topic_name,omitempty" xml:"topic_name,omitempty" yaml:"topic_name,omitempty"`
+	Subject                   string                   `json:"subject,omitempty" xml:"subject,omitempty" yaml:"subject,omitempty"`
+	EventBusName              string                   `json:"event_bus_name,omitempty" xml:"event_bus_name,omitempty" yaml:"event_bus_name,omitempty"`
+	EventSource               string                   `json:"event_source,omitempty" xml:"event_source,omitempty" yaml:"event_source,omitempty"`
+	EventDetailType           string                   `json:"event_detail_type,omitempty" xml:"event_detail_type,omitempty" yaml:"event_detail_type,omitempty"`
+	EventBatch                []map[string]interface{} `json:"event_batch,omitempty" xml:"event_batch,omitempty" yaml:"event_batch,omitempty"`
+	BucketName                string                   `json:"bucket_name,omitempty" xml:"bucket_name,omitempty" yaml:"bucket_name,omitempty"`
+	ObjectKey                 string                   `json:"object_key,omitempty" xml:"object_key,omitempty" yaml:"object_key,omitempty"`
+	ObjectPrefix              string                   `json:"object_prefix,omitempty" xml:"object_prefix,omitempty" yaml:"object_prefix,omitempty"`
+	MinObjectCount            int64                    `json:"min_object_count,omitempty" xml:"min_object_count,omitempty" yaml:"min_object_count,omitempty"`
+	MinObjectSize             int64                    `json:"min_object_size,omitempty" xml:"min_object_size,omitempty" yaml:"min_object_size,omitempty"`
+	ModifiedAfter             string                   `json:"modified_after,omitempty" xml:"modified_after,omitempty" yaml:"modified_after,omitempty"`
+	DestBucketName            string                   `json:"destination_bucket_name,omitempty" xml:"destination_bucket_name,omitempty" yaml:"destination_bucket_name,omitempty"`
+	DestObjectKey             string                   `json:"destination_object_key,omitempty" xml:"destination_object_key,omitempty" yaml:"destination_object_key,omitempty"`
+	DestObjectPrefix          string                   `json:"destination_object_prefix,omitempty" xml:"destination_object_prefix,omitempty" yaml:"destination_object_prefix,omitempty"`
+	ObjectTags                map[string]string        `json:"object_tags,omitempty" xml:"object_tags,omitempty" yaml:"object_tags,omitempty"`
+	TableName                 string                   `json:"table_name,omitempty" xml:"table_name,omitempty" yaml:"table_name,omitempty"`
+	Item                      map[string]interface{}   `json:"item,omitempty" xml:"item,omitempty" yaml:"item,omitempty"`
+	ItemKey                   map[string]interface{}   `json:"item_key,omitempty" xml:"item_key,omitempty" yaml:"item_key,omitempty"`
+	ConditionExpression       string                   `json:"condition_expression,omitempty" xml:"condition_expression,omitempty" yaml:"condition_expression,omitempty"`
+	UpdateExpression          string                   `json:"update_expression,omitempty" xml:"update_expression,omitempty" yaml:"update_expression,omitempty"`
+	ExpressionAttributeNames  map[string]string        `json:"expression_attribute_names,omitempty" xml:"expression_attribute_names,omitempty" yaml:"expression_attribute_names,omitempty"`
+	ExpressionAttributeValues map[string]interface{}   `json:"expression_attribute_values,omitempty" xml:"expression_attribute_values,omitempty" yaml:"expression_attribute_values,omitempty"`
+	WaitAttributeName         string                   `json:"wait_attribute_name,omitempty" xml:"wait_attribute_name,omitempty" yaml:"wait_attribute_name,omitempty"`
+	WaitAttributeValue        interface{}              `json:"wait_attribute_value,omitempty" xml:"wait_attribute_value,omitempty" yaml:"wait_attribute_value,omitempty"`
+	DocumentName              string                   `json:"document_name,omitempty" xml:"document_name,omitempty" yaml:"document_name,omitempty"`
+	InstanceIDs               []string                 `json:"instance_ids,omitempty" xml:"instance_ids,omitempty" yaml:"instance_ids,omitempty"`
+	TargetTags                map[string]string        `json:"target_tags,omitempty" xml:"target_tags,omitempty" yaml:"target_tags,omitempty"`
+	MaxConcurrency            string                   `json:"max_concurrency,omitempty" xml:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"`
+	MaxErrors                 string                   `json:"max_errors,omitempty" xml:"max_errors,omitempty" yaml:"max_errors,omitempty"`
+	StackName                 string                   `json:"stack_name,omitempty" xml:"stack_name,omitempty" yaml:"stack_name,omitempty"`
+	TemplateBody              string                   `json:"template_body,omitempty" xml:"template_body,omitempty" yaml:"template_body,omitempty"`
+	TemplateURL               string                   `json:"template_url,omitempty" xml:"template_url,omitempty" yaml:"template_url,omitempty"`
+	Capabilities              []string                 `json:"capabilities,omitempty" xml:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	StackTags                 map[string]string        `json:"stack_tags,omitempty" xml:"stack_tags,omitempty" yaml:"stack_tags,omitempty"`
+	Operation                 string                   `json:"operation,omitempty" xml:"operation,omitempty" yaml:"operation,omitempty"`
+	DBIdentifier              string                   `json:"db_identifier,omitempty" xml:"db_identifier,omitempty" yaml:"db_identifier,omitempty"`
+	SnapshotIdentifier        string                   `json:"snapshot_identifier,omitempty" xml:"snapshot_identifier,omitempty" yaml:"snapshot_identifier,omitempty"`
+	LogTailLines              int64                    `json:"log_tail_lines,omitempty" xml:"log_tail_lines,omitempty" yaml:"log_tail_lines,omitempty"`
+	FetchLogs                 bool                     `json:"fetch_logs,omitempty" xml:"fetch_logs,omitempty" yaml:"fetch_logs,omitempty"`
+
+	// ctx is the context of the request, which carries its trace span.
+	ctx context.Context
+	// nodeID is the ID of the workflow node executing the template.
+	nodeID string
 }
 
 // Validate validates Plugin input arguments.
@@ -65,11 +230,22 @@ func (req *PluginRequest) Validate() error {
 		return fmt.Errorf("region name is empty")
 	}
 
+	if req.LogTailLines < 0 {
+		return fmt.Errorf("log_tail_lines must be a positive number")
+	}
+	if req.LogTailLines > maxLogTailLines {
+		return fmt.Errorf("log_tail_lines must not exceed %d", maxLogTailLines)
+	}
+
 	if _, exists := allowedServiceNames[req.ServiceName]; !exists {
 		return fmt.Errorf("service '%s' is not supported", req.ServiceName)
 	}
 
-	if _, exists := allowedActions[req.Action]; !exists {
+	actions, exists := allowedServiceActions[req.ServiceName]
+	if !exists {
+		actions = allowedActions
+	}
+	if _, exists := actions[req.Action]; !exists {
 		return fmt.Errorf("action '%s' is not supported", req.Action)
 	}
 
@@ -80,10 +256,32 @@ func (req *PluginRequest) Validate() error {
 		}
 		req.ResourceArn = fmt.Sprintf("arn:aws:sagemaker:%s:%s:pipeline/%s", req.RegionName, req.AccountID, req.PipelineName)
 	case "aws_glue":
-		if req.JobName == "" {
-			return fmt.Errorf("job_name is empty")
+		if req.Kind == "" {
+			req.Kind = "job"
+		}
+		if _, exists := allowedGlueKinds[req.Kind]; !exists {
+			return fmt.Errorf("kind '%s' is not supported for aws_glue", req.Kind)
+		}
+		switch req.Kind {
+		case "job":
+			if req.JobName == "" {
+				return fmt.Errorf("job_name is empty")
+			}
+			if err := req.validateGlueJobRunOptions(); err != nil {
+				return err
+			}
+			req.ResourceArn = fmt.Sprintf("arn:aws:glue:%s:%s:job/%s", req.RegionName, req.AccountID, req.JobName)
+		case "crawler":
+			if req.CrawlerName == "" {
+				return fmt.Errorf("crawler_name is empty")
+			}
+			req.ResourceArn = fmt.Sprintf("arn:aws:glue:%s:%s:crawler/%s", req.RegionName, req.AccountID, req.CrawlerName)
+		case "workflow":
+			if req.GlueWorkflowName == "" {
+				return fmt.Errorf("glue_workflow_name is empty")
+			}
+			req.ResourceArn = fmt.Sprintf("arn:aws:glue:%s:%s:workflow/%s", req.RegionName, req.AccountID, req.GlueWorkflowName)
 		}
-		req.ResourceArn = fmt.Sprintf("arn:aws:glue:%s:%s:job/%s", req.RegionName, req.AccountID, req.JobName)
 	case "aws_step_functions":
 		if req.StepFunctionName == "" {
 			return fmt.Errorf("step_function_name is empty")
@@ -94,6 +292,127 @@ func (req *PluginRequest) Validate() error {
 			return fmt.Errorf("lambda_function_name is empty")
 		}
 		req.ResourceArn = fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", req.RegionName, req.AccountID, req.LambdaFunctionName)
+	case "amazon_redshift_data":
+		if err := req.validateRedshiftData(); err != nil {
+			return err
+		}
+	case "aws_codebuild":
+		if req.ProjectName == "" {
+			return fmt.Errorf("project_name is empty")
+		}
+		req.ResourceArn = fmt.Sprintf("arn:aws:codebuild:%s:%s:project/%s", req.RegionName, req.AccountID, req.ProjectName)
+	case "amazon_sqs":
+		if err := req.validateSQS(); err != nil {
+			return err
+		}
+	case "amazon_sns":
+		if req.TopicName == "" {
+			return fmt.Errorf("topic_name is empty")
+		}
+		if req.Action == "execute" && req.MessageBody == "" && len(req.Parameters) == 0 {
+			return fmt.Errorf("message_body and parameters are empty")
+		}
+		if strings.HasSuffix(req.TopicName, ".fifo") && req.Action == "execute" && req.MessageGroupID == "" {
+			return fmt.Errorf("message_group_id is required for fifo topic")
+		}
+		req.ResourceArn = fmt.Sprintf("arn:aws:sns:%s:%s:%s", req.RegionName, req.AccountID, req.TopicName)
+	case "amazon_eventbridge":
+		if req.EventBusName == "" {
+			req.EventBusName = "default"
+		}
+		if req.Action == "execute" {
+			if req.EventSource == "" {
+				return fmt.Errorf("event_source is empty")
+			}
+			if req.EventDetailType == "" {
+				return fmt.Errorf("event_detail_type is empty")
+			}
+			if len(req.Parameters) > 0 && len(req.EventBatch) > 0 {
+				return fmt.Errorf("parameters and event_batch are mutually exclusive")
+			}
+		}
+		req.ResourceArn = fmt.Sprintf("arn:aws:events:%s:%s:event-bus/%s", req.RegionName, req.AccountID, req.EventBusName)
+	case "amazon_s3":
+		if err := req.validateS3(); err != nil {
+			return err
+		}
+	case "amazon_dynamodb":
+		if err := req.validateDynamoDB(); err != nil {
+			return err
+		}
+	case "aws_ssm":
+		if err := req.validateSSM(); err != nil {
+			return err
+		}
+	case "aws_cloudformation":
+		if req.StackName == "" {
+			return fmt.Errorf("stack_name is empty")
+		}
+		if req.TemplateBody == "" && req.TemplateURL == "" {
+			return fmt.Errorf("template_body and template_url are empty")
+		}
+		if req.TemplateBody != "" && req.TemplateURL != "" {
+			return fmt.Errorf("template_body and template_url are mutually exclusive")
+		}
+		for _, capability := range req.Capabilities {
+			if _, exists := allowedCloudFormationCapabilities[capability]; !exists {
+				return fmt.Errorf("capability '%s' is not supported", capability)
+			}
+		}
+		req.ResourceArn = fmt.Sprintf("arn:aws:cloudformation:%s:%s:stack/%s/*", req.RegionName, req.AccountID, req.StackName)
+	case "amazon_ec2":
+		if len(req.InstanceIDs) == 0 && len(req.TargetTags) == 0 {
+			return fmt.Errorf("instance_ids and target_tags are empty")
+		}
+		if len(req.InstanceIDs) > 0 && len(req.TargetTags) > 0 {
+			return fmt.Errorf("instance_ids and target_tags are mutually exclusive")
+		}
+		if req.Action == "execute" {
+			if _, exists := allowedEC2Operations[req.Operation]; !exists {
+				return fmt.Errorf("operation '%s' is not supported for amazon_ec2", req.Operation)
+			}
+		}
+		if len(req.InstanceIDs) == 1 {
+			req.ResourceArn = fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", req.RegionName, req.AccountID, req.InstanceIDs[0])
+		} else {
+			req.ResourceArn = fmt.Sprintf("arn:aws:ec2:%s:%s:instance/*", req.RegionName, req.AccountID)
+		}
+	case "amazon_rds":
+		if req.Kind == "" {
+			req.Kind = "instance"
+		}
+		if _, exists := allowedRDSKinds[req.Kind]; !exists {
+			return fmt.Errorf("kind '%s' is not supported for amazon_rds", req.Kind)
+		}
+		if req.DBIdentifier == "" {
+			return fmt.Errorf("db_identifier is empty")
+		}
+		if req.Action == "execute" {
+			if _, exists := allowedRDSOperations[req.Operation]; !exists {
+				return fmt.Errorf("operation '%s' is not supported for amazon_rds", req.Operation)
+			}
+		}
+		if req.SnapshotIdentifier != "" && req.Operation != "snapshot" {
+			return fmt.Errorf("snapshot_identifier is only supported with operation snapshot")
+		}
+		resourceType := map[string]string{
+			"instance": "db",
+			"cluster":  "cluster",
+		}[req.Kind]
+		req.ResourceArn = fmt.Sprintf("arn:aws:rds:%s:%s:%s:%s", req.RegionName, req.AccountID, resourceType, req.DBIdentifier)
+	case "amazon_sagemaker_training", "amazon_sagemaker_processing", "amazon_sagemaker_transform":
+		if req.JobName == "" {
+			return fmt.Errorf("job_name is empty")
+		}
+		if len(req.JobSpec) == 0 {
+			return fmt.Errorf("job_spec is empty")
+		}
+		resourceType := map[string]string{
+			"amazon_sagemaker_training":   "training-job",
+			"amazon_sagemaker_processing": "processing-job",
+			"amazon_sagemaker_transform":  "transform-job",
+		}[req.ServiceName]
+		req.ResourceArn = fmt.Sprintf("arn:aws:sagemaker:%s:%s:%s/%s", req.RegionName, req.AccountID, resourceType, req.JobName)
 	}
 
 	if req.Mock {
@@ -106,3 +425,269 @@ func (req *PluginRequest) Validate() error {
 	}
 	return nil
 }
+
+func (req *PluginRequest) validateRedshiftData() error {
+	switch {
+	case req.ClusterIdentifier == "" && req.WorkgroupName == "":
+		return fmt.Errorf("cluster_identifier and workgroup_name are empty")
+	case req.ClusterIdentifier != "" && req.WorkgroupName != "":
+		return fmt.Errorf("cluster_identifier and workgroup_name are mutually exclusive")
+	case req.DatabaseName == "":
+		return fmt.Errorf("database_name is empty")
+	case req.DatabaseUser != "" && req.SecretArn != "":
+		return fmt.Errorf("database_user and secret_arn are mutually exclusive")
+	case req.DatabaseUser != "" && req.WorkgroupName != "":
+		return fmt.Errorf("database_user is not supported with workgroup_name")
+	}
+	if req.Action == "execute" {
+		if req.SQL == "" && len(req.SQLBatch) == 0 {
+			return fmt.Errorf("sql and sql_batch are empty")
+		}
+		if req.SQL != "" && len(req.SQLBatch) > 0 {
+			return fmt.Errorf("sql and sql_batch are mutually exclusive")
+		}
+	}
+	if req.ClusterIdentifier != "" {
+		req.ResourceArn = fmt.Sprintf("arn:aws:redshift:%s:%s:dbname:%s/%s", req.RegionName, req.AccountID, req.ClusterIdentifier, req.DatabaseName)
+	} else {
+		req.ResourceArn = fmt.Sprintf("arn:aws:redshift-serverless:%s:%s:workgroup/%s", req.RegionName, req.AccountID, req.WorkgroupName)
+	}
+	return nil
+}
+
+func (req *PluginRequest) validateGlueJobRunOptions() error {
+	if req.WorkerType != "" {
+		if _, exists := allowedGlueWorkerTypes[req.WorkerType]; !exists {
+			return fmt.Errorf("worker_type '%s' is not supported", req.WorkerType)
+		}
+		if req.NumberOfWorkers < 1 {
+			return fmt.Errorf("number_of_workers must be set when worker_type is set")
+		}
+	}
+	if req.NumberOfWorkers < 0 {
+		return fmt.Errorf("number_of_workers must be a positive number")
+	}
+	if req.NumberOfWorkers > 0 && req.WorkerType == "" {
+		return fmt.Errorf("worker_type must be set when number_of_workers is set")
+	}
+	if req.JobTimeout < 0 {
+		return fmt.Errorf("job_timeout must be a positive number of minutes")
+	}
+	if req.NotifyDelayAfter < 0 {
+		return fmt.Errorf("notify_delay_after must be a positive number of minutes")
+	}
+	if req.ExecutionClass != "" {
+		if _, exists := allowedGlueExecutionClasses[req.ExecutionClass]; !exists {
+			return fmt.Errorf("execution_class '%s' is not supported", req.ExecutionClass)
+		}
+		if req.ExecutionClass == "FLEX" && req.WorkerType != "" && req.WorkerType != "G.1X" && req.WorkerType != "G.2X" {
+			return fmt.Errorf("execution_class FLEX is not supported with worker_type '%s'", req.WorkerType)
+		}
+	}
+	if req.JobBookmarkOption != "" {
+		if _, exists := allowedGlueJobBookmarkOptions[req.JobBookmarkOption]; !exists {
+			return fmt.Errorf("job_bookmark_option '%s' is not supported", req.JobBookmarkOption)
+		}
+	}
+	if req.JobBookmarkFrom != "" || req.JobBookmarkTo != "" {
+		if req.JobBookmarkOption != "pause" {
+			return fmt.Errorf("job_bookmark_from and job_bookmark_to require job_bookmark_option pause")
+		}
+		if req.JobBookmarkFrom == "" || req.JobBookmarkTo == "" {
+			return fmt.Errorf("job_bookmark_from and job_bookmark_to must be set together")
+		}
+	}
+	if req.ResetJobBookmark && req.JobBookmarkOption == "pause" {
+		return fmt.Errorf("reset_job_bookmark is not supported with job_bookmark_option pause")
+	}
+	return nil
+}
+
+func (req *PluginRequest) validateSQS() error {
+	if req.WaitTimeout < 0 {
+		return fmt.Errorf("wait_timeout must be a positive number of seconds")
+	}
+	switch req.Action {
+	case "validate", "send":
+		if req.QueueName == "" {
+			return fmt.Errorf("queue_name is empty")
+		}
+	case "await":
+		if req.ReplyQueueName == "" {
+			return fmt.Errorf("reply_queue_name is empty")
+		}
+	}
+	if req.Action == "send" {
+		if req.MessageBody != "" && len(req.MessageBatch) > 0 {
+			return fmt.Errorf("message_body and message_batch are mutually exclusive")
+		}
+		if req.MessageBody == "" && len(req.MessageBatch) == 0 && len(req.Parameters) == 0 {
+			return fmt.Errorf("message_body, message_batch and parameters are empty")
+		}
+		if strings.HasSuffix(req.QueueName, ".fifo") && req.MessageGroupID == "" {
+			return fmt.Errorf("message_group_id is required for fifo queue")
+		}
+	}
+	queueName := req.QueueName
+	if req.Action == "await" {
+		queueName = req.ReplyQueueName
+	}
+	req.ResourceArn = fmt.Sprintf("arn:aws:sqs:%s:%s:%s", req.RegionName, req.AccountID, queueName)
+	return nil
+}
+
+func (req *PluginRequest) validateS3() error {
+	if req.BucketName == "" {
+		return fmt.Errorf("bucket_name is empty")
+	}
+	if req.WaitTimeout < 0 {
+		return fmt.Errorf("wait_timeout must be a positive number of seconds")
+	}
+	if req.Action == "wait" {
+		if req.ObjectKey != "" && req.ObjectPrefix != "" {
+			return fmt.Errorf("object_key and object_prefix are mutually exclusive")
+		}
+		if req.MinObjectCount < 0 {
+			return fmt.Errorf("min_object_count must be a positive number")
+		}
+		if req.MinObjectCount > 1 && req.ObjectKey != "" {
+			return fmt.Errorf("min_object_count is not supported with object_key")
+		}
+		if req.MinObjectSize < 0 {
+			return fmt.Errorf("min_object_size must be a positive number of bytes")
+		}
+		if req.ModifiedAfter != "" {
+			if _, err := time.Parse(time.RFC3339, req.ModifiedAfter); err != nil {
+				return fmt.Errorf("modified_after is not RFC3339 timestamp: %s", err)
+			}
+		}
+	}
+	switch req.Action {
+	case "copy", "delete", "tag":
+		if req.ObjectKey == "" && req.ObjectPrefix == "" {
+			return fmt.Errorf("object_key and object_prefix are empty")
+		}
+		if req.ObjectKey != "" && req.ObjectPrefix != "" {
+			return fmt.Errorf("object_key and object_prefix are mutually exclusive")
+		}
+	}
+	switch req.Action {
+	case "copy":
+		if req.ObjectKey != "" && req.DestObjectPrefix != "" {
+			return fmt.Errorf("destination_object_prefix is not supported with object_key")
+		}
+		if req.ObjectPrefix != "" && req.DestObjectKey != "" {
+			return fmt.Errorf("destination_object_key is not supported with object_prefix")
+		}
+		dstBucket := req.DestBucketName
+		if dstBucket == "" {
+			dstBucket = req.BucketName
+		}
+		if dstBucket == req.BucketName {
+			if req.ObjectKey != "" && (req.DestObjectKey == "" || req.DestObjectKey == req.ObjectKey) {
+				return fmt.Errorf("source and destination objects are the same")
+			}
+			// The listing of the source prefix would include the objects
+			// copied under the destination prefix.
+			if req.ObjectPrefix != "" && strings.HasPrefix(req.DestObjectPrefix, req.ObjectPrefix) {
+				return fmt.Errorf("destination prefix is within source prefix")
+			}
+		}
+	case "tag":
+		if len(req.ObjectTags) == 0 {
+			return fmt.Errorf("object_tags is empty")
+		}
+	}
+	switch {
+	case req.ObjectKey != "":
+		req.ResourceArn = fmt.Sprintf("arn:aws:s3:::%s/%s", req.BucketName, req.ObjectKey)
+	case req.ObjectPrefix != "":
+		req.ResourceArn = fmt.Sprintf("arn:aws:s3:::%s/%s*", req.BucketName, req.ObjectPrefix)
+	default:
+		req.ResourceArn = fmt.Sprintf("arn:aws:s3:::%s", req.BucketName)
+	}
+	return nil
+}
+
+func (req *PluginRequest) validateDynamoDB() error {
+	if req.TableName == "" {
+		return fmt.Errorf("table_name is empty")
+	}
+	if req.WaitTimeout < 0 {
+		return fmt.Errorf("wait_timeout must be a positive number of seconds")
+	}
+	switch req.Action {
+	case "put":
+		if len(req.Item) == 0 {
+			return fmt.Errorf("item is empty")
+		}
+	case "update":
+		if len(req.ItemKey) == 0 {
+			return fmt.Errorf("item_key is empty")
+		}
+		if req.UpdateExpression == "" {
+			return fmt.Errorf("update_expression is empty")
+		}
+	case "get":
+		if len(req.ItemKey) == 0 {
+			return fmt.Errorf("item_key is empty")
+		}
+	case "wait":
+		if len(req.ItemKey) == 0 {
+			return fmt.Errorf("item_key is empty")
+		}
+		if req.WaitAttributeName == "" {
+			return fmt.Errorf("wait_attribute_name is empty")
+		}
+	}
+	if req.ConditionExpression != "" && req.Action != "put" && req.Action != "update" {
+		return fmt.Errorf("condition_expression is not supported with action '%s'", req.Action)
+	}
+	req.ResourceArn = fmt.Sprintf("arn:aws:dynamodb:%s:%s:table/%s", req.RegionName, req.AccountID, req.TableName)
+	return nil
+}
+
+func (req *PluginRequest) validateSSM() error {
+	if req.Kind == "" {
+		req.Kind = "command"
+	}
+	if _, exists := allowedSSMKinds[req.Kind]; !exists {
+		return fmt.Errorf("kind '%s' is not supported for aws_ssm", req.Kind)
+	}
+	if req.DocumentName == "" {
+		return fmt.Errorf("document_name is empty")
+	}
+	switch req.Kind {
+	case "command":
+		if req.Action == "execute" {
+			if len(req.InstanceIDs) == 0 && len(req.TargetTags) == 0 {
+				return fmt.Errorf("instance_ids and target_tags are empty")
+			}
+			if len(req.InstanceIDs) > 0 && len(req.TargetTags) > 0 {
+				return fmt.Errorf("instance_ids and target_tags are mutually exclusive")
+			}
+			if len(req.InstanceIDs) > 50 {
+				return fmt.Errorf("instance_ids exceeds the limit of 50 instances")
+			}
+		}
+	case "automation":
+		if len(req.InstanceIDs) > 0 || len(req.TargetTags) > 0 {
+			return fmt.Errorf("instance_ids and target_tags are not supported for automation")
+		}
+	}
+	req.ResourceArn = fmt.Sprintf("arn:aws:ssm:%s:%s:document/%s", req.RegionName, req.AccountID, req.DocumentName)
+	return nil
+}
+
+// DecodeJobSpec decodes the job specification of the request into the input
+// structure of the corresponding AWS API call.
+func (req *PluginRequest) DecodeJobSpec(v interface{}) error {
+	b, err := json.Marshal(req.JobSpec)
+	if err != nil {
+		return fmt.Errorf("failed to encode job_spec: %s", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode job_spec: %s", err)
+	}
+	return nil
+}
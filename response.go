@@ -14,7 +14,10 @@
 
 package main
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	wfv1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // PluginResponse contains plugin response.
 type PluginResponse struct {
@@ -24,4 +27,20 @@ type PluginResponse struct {
 	RequeueDuration *metav1.Duration     `json:"requeue_duration,omitempty" xml:"requeue_duration,omitempty" yaml:"requeue_duration,omitempty"`
 	RequestError    error                `json:"req_error,omitempty" xml:"req_error,omitempty" yaml:"req_error,omitempty"`
 	ExecutionError  error                `json:"exec_error,omitempty" xml:"exec_error,omitempty" yaml:"exec_error,omitempty"`
+	Outputs         map[string]string    `json:"outputs,omitempty" xml:"outputs,omitempty" yaml:"outputs,omitempty"`
+	FailureCategory FailureCategory      `json:"failure_category,omitempty" xml:"failure_category,omitempty" yaml:"failure_category,omitempty"`
+	Progress        wfv1.Progress        `json:"progress,omitempty" xml:"progress,omitempty" yaml:"progress,omitempty"`
+}
+
+// newProgress returns the progress of completed out of total items, or
+// undefined progress when the total is unknown.
+func newProgress(completed, total int64) wfv1.Progress {
+	if completed > total {
+		completed = total
+	}
+	progress, ok := wfv1.NewProgress(completed, total)
+	if !ok {
+		return wfv1.ProgressUndefined
+	}
+	return progress
 }
@@ -0,0 +1,41 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// newAWSSession creates AWS session for the region of the request. The
+// session records the metrics and the trace spans of AWS API calls.
+func newAWSSession(req *PluginRequest) (*session.Session, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(req.RegionName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sess.Handlers.Complete.PushBackNamed(awsAPIMetricsHandler)
+
+	ctx := req.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sess.Handlers.Complete.PushBackNamed(newAWSAPITracingHandler(ctx))
+	return sess, nil
+}
@@ -30,6 +30,8 @@ const (
 	ERROR
 	// RUNNING identifies the workflow is still running (2).
 	RUNNING
+	// FAILED identifies the workflow whose remote job failed (4).
+	FAILED
 )
 
 // String returns the description for IdentityProviderType enum.
@@ -41,6 +43,8 @@ func (m PluginWorkflowStatus) String() string {
 		return "error"
 	case RUNNING:
 		return "running"
+	case FAILED:
+		return "failed"
 	}
 	return fmt.Sprintf("PluginWorkflowStatus(%d)", int(m))
 }
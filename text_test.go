@@ -0,0 +1,75 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTruncateText(t *testing.T) {
+	var testcases = []struct {
+		name      string
+		text      string
+		n         int
+		want      string
+		wantStart string
+	}{
+		{
+			name:      "test short text",
+			text:      "foo",
+			n:         3,
+			want:      "foo",
+			wantStart: "foo",
+		},
+		{
+			name:      "test long text",
+			text:      "foobar",
+			n:         3,
+			want:      "foo...",
+			wantStart: "...bar",
+		},
+		{
+			name:      "test multibyte characters",
+			text:      "aéééb",
+			n:         4,
+			want:      "aé...",
+			wantStart: "...éb",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := map[string]interface{}{
+				"text":       truncateText(tc.text, tc.n),
+				"text_start": truncateTextStart(tc.text, tc.n),
+			}
+			want := map[string]interface{}{
+				"text":       tc.want,
+				"text_start": tc.wantStart,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+			for k, v := range got {
+				if !utf8.ValidString(v.(string)) {
+					t.Fatalf("test name: %s, %s is not valid utf-8: %q", tc.name, k, v)
+				}
+			}
+		})
+	}
+}
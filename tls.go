@@ -0,0 +1,164 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// certificateReloader serves the TLS certificate stored in files. The
+// certificate is reloaded when either file changes, e.g. on renewal.
+type certificateReloader struct {
+	sync.RWMutex
+	certFile    string
+	keyFile     string
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// newCertificateReloader returns the reloader for the certificate and key
+// files. It fails when the certificate cannot be loaded.
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	r := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if _, err := r.getCertificate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// getCertificate returns the certificate, reloading it when the files have
+// changed.
+func (r *certificateReloader) getCertificate() (*tls.Certificate, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat tls certificate file: %s", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat tls key file: %s", err)
+	}
+
+	r.RLock()
+	if r.cert != nil && certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime) {
+		cert := r.cert
+		r.RUnlock()
+		return cert, nil
+	}
+	r.RUnlock()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		r.RLock()
+		defer r.RUnlock()
+		if r.cert != nil {
+			// The files may be mid-rotation. Keep serving the previous
+			// certificate until both files are updated.
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load tls certificate: %s", err)
+	}
+
+	r.Lock()
+	r.cert = &cert
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	r.Unlock()
+	return &cert, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.getCertificate()
+}
+
+// configureTLS returns the TLS configuration of the HTTP server. It returns
+// nil when TLS is not configured and the server uses plain HTTP.
+func (ex *ExecutorPlugin) configureTLS() (*tls.Config, error) {
+	if ex.TLSCertFile == "" && ex.TLSKeyFile == "" {
+		if ex.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("tls client ca file requires tls certificate and key files")
+		}
+		return nil, nil
+	}
+	if ex.TLSCertFile == "" || ex.TLSKeyFile == "" {
+		return nil, fmt.Errorf("tls requires both certificate and key files")
+	}
+
+	reloader, err := newCertificateReloader(ex.TLSCertFile, ex.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if ex.TLSClientCAFile != "" {
+		b, err := os.ReadFile(ex.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls client ca file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("failed to parse tls client ca file: no certificates found")
+		}
+		// The health checks and the scrapes of the metrics do not present
+		// client certificates. The certificates are required by the
+		// template.execute handler only, see handleClientCertificate.
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
+
+// handleClientCertificate wraps the handler with the requirement of verified
+// client certificate, when the verification of client certificates is
+// configured.
+func handleClientCertificate(ex *ExecutorPlugin, next http.HandlerFunc) http.HandlerFunc {
+	if ex.TLSClientCAFile == "" {
+		return next
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		// The chains are verified during the handshake, because the
+		// certificates given by clients are verified.
+		if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+			metricRequests.WithLabelValues("", "", "unauthenticated").Inc()
+			ex.Logger.Warn(
+				"failed to authenticate request",
+				zap.String("plugin_name", app.Name),
+				zap.String("remote_addr", req.RemoteAddr),
+				zap.Int("status_code", http.StatusForbidden),
+				zap.Error(fmt.Errorf("client certificate not found")),
+			)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next(w, req)
+	}
+}
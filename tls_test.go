@@ -0,0 +1,321 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap/zapcore"
+)
+
+func writeTestCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Ensure the modification time changes on filesystems with coarse
+	// timestamps.
+	modTime := time.Now().Add(time.Duration(serial) * time.Second)
+	for _, fp := range []string{certFile, keyFile} {
+		if err := os.Chtimes(fp, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCertificateReloader(t *testing.T) {
+	tmpDir := t.TempDir()
+	certFile := filepath.Join(tmpDir, "tls.crt")
+	keyFile := filepath.Join(tmpDir, "tls.key")
+	writeTestCertificate(t, certFile, keyFile, 1)
+
+	r, err := newCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []struct {
+		name   string
+		rotate int64
+		want   int64
+	}{
+		{
+			name: "test initial certificate",
+			want: 1,
+		},
+		{
+			name:   "test certificate after rotation",
+			rotate: 2,
+			want:   2,
+		},
+		{
+			name: "test certificate without changes",
+			want: 2,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.rotate > 0 {
+				writeTestCertificate(t, certFile, keyFile, tc.rotate)
+			}
+			cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, leaf.SerialNumber.Int64()); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestConfigureTLS(t *testing.T) {
+	tmpDir := t.TempDir()
+	certFile := filepath.Join(tmpDir, "tls.crt")
+	keyFile := filepath.Join(tmpDir, "tls.key")
+	writeTestCertificate(t, certFile, keyFile, 1)
+
+	var testcases = []struct {
+		name      string
+		ex        *ExecutorPlugin
+		want      map[string]interface{}
+		shouldErr bool
+		err       error
+	}{
+		{
+			name: "test plain http",
+			ex:   &ExecutorPlugin{},
+			want: map[string]interface{}{
+				"enabled": false,
+			},
+		},
+		{
+			name: "test tls",
+			ex: &ExecutorPlugin{
+				TLSCertFile: certFile,
+				TLSKeyFile:  keyFile,
+			},
+			want: map[string]interface{}{
+				"enabled":     true,
+				"client_auth": tls.NoClientCert.String(),
+			},
+		},
+		{
+			name: "test tls with client certificate verification",
+			ex: &ExecutorPlugin{
+				TLSCertFile:     certFile,
+				TLSKeyFile:      keyFile,
+				TLSClientCAFile: certFile,
+			},
+			want: map[string]interface{}{
+				"enabled":     true,
+				"client_auth": tls.VerifyClientCertIfGiven.String(),
+			},
+		},
+		{
+			name: "test tls without key file",
+			ex: &ExecutorPlugin{
+				TLSCertFile: certFile,
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("tls requires both certificate and key files"),
+		},
+		{
+			name: "test client ca file without tls",
+			ex: &ExecutorPlugin{
+				TLSClientCAFile: certFile,
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("tls client ca file requires tls certificate and key files"),
+		},
+		{
+			name: "test invalid client ca file",
+			ex: &ExecutorPlugin{
+				TLSCertFile:     certFile,
+				TLSKeyFile:      keyFile,
+				TLSClientCAFile: keyFile,
+			},
+			shouldErr: true,
+			err:       fmt.Errorf("failed to parse tls client ca file: no certificates found"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := tc.ex.configureTLS()
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatalf("test name: %s, expected error, but got success", tc.name)
+				}
+				if diff := cmp.Diff(tc.err.Error(), err.Error()); diff != "" {
+					t.Fatalf("test name: %s, unexpected error (-want +got):\n%s", tc.name, diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, err)
+			}
+			got := map[string]interface{}{
+				"enabled": cfg != nil,
+			}
+			if cfg != nil {
+				got["client_auth"] = cfg.ClientAuth.String()
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	tmpDir := t.TempDir()
+	certFile := filepath.Join(tmpDir, "tls.crt")
+	keyFile := filepath.Join(tmpDir, "tls.key")
+	writeTestCertificate(t, certFile, keyFile, 1)
+
+	ex := &ExecutorPlugin{
+		Logger:          NewLogger(zapcore.DebugLevel),
+		TLSCertFile:     certFile,
+		TLSKeyFile:      keyFile,
+		TLSClientCAFile: certFile,
+	}
+	cfg, err := ex.configureTLS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/template.execute", handleClientCertificate(ex, ok))
+	mux.HandleFunc("/healthz", ok)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Handler:   mux,
+		TLSConfig: cfg,
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+	srvURL := "https://" + ln.Addr().String()
+
+	b, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(b)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []struct {
+		name       string
+		path       string
+		clientCert bool
+		want       int
+	}{
+		{
+			name: "test health check without client certificate",
+			path: "/healthz",
+			want: http.StatusOK,
+		},
+		{
+			name: "test template.execute without client certificate",
+			path: "/api/v1/template.execute",
+			want: http.StatusForbidden,
+		},
+		{
+			name:       "test template.execute with client certificate",
+			path:       "/api/v1/template.execute",
+			clientCert: true,
+			want:       http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tlsConfig := &tls.Config{RootCAs: pool}
+			if tc.clientCert {
+				tlsConfig.Certificates = []tls.Certificate{cert}
+			}
+			client := &http.Client{
+				Timeout:   5 * time.Second,
+				Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			}
+			resp, err := client.Get(srvURL + tc.path)
+			if err != nil {
+				t.Fatalf("test name: %s, expected success, but got error: %v", tc.name, err)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if diff := cmp.Diff(tc.want, resp.StatusCode); diff != "" {
+				t.Fatalf("test name: %s, unexpected result (-want +got):\n%s", tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/greenpau/argo-workflows-aws-plugin"
+
+// tracer returns the tracer of the plugin. The spans are discarded unless
+// the export of traces is configured.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// configureTracing configures the export of traces to OTLP collector. It
+// returns the function flushing the spans and stopping the export.
+func (ex *ExecutorPlugin) configureTracing(ctx context.Context) (func(context.Context) error, error) {
+	if ex.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(ex.OTLPEndpoint),
+	}
+	if ex.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp trace exporter: %s", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(app.Name),
+			semconv.ServiceVersionKey.String(app.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newAWSAPITracingHandler returns the handler recording AWS API calls as the
+// child spans of the span in the context.
+func newAWSAPITracingHandler(ctx context.Context) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "awf.AWSAPITracingHandler",
+		Fn: func(r *request.Request) {
+			_, span := tracer().Start(ctx, fmt.Sprintf("%s.%s", r.ClientInfo.ServiceName, r.Operation.Name),
+				trace.WithTimestamp(r.Time),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.RPCSystemKey.String("aws-api"),
+					semconv.RPCServiceKey.String(r.ClientInfo.ServiceName),
+					semconv.RPCMethodKey.String(r.Operation.Name),
+					attribute.String("aws.region", aws.StringValue(r.Config.Region)),
+					attribute.String("aws.request_id", r.RequestID),
+					attribute.Int("aws.retry_count", r.RetryCount),
+				),
+			)
+			if r.Error != nil {
+				span.RecordError(r.Error)
+				span.SetStatus(codes.Error, r.Error.Error())
+			}
+			span.End()
+		},
+	}
+}
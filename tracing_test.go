@@ -0,0 +1,84 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestExecutionTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	tracker := &executionTracker{
+		executions: make(map[string]*trackedExecution),
+	}
+
+	ctx, span := tracer().Start(context.Background(), "template.execute")
+	tracker.update(ctx, "foo", "copy-a1b2c3d4e5f6", "test_service", &PluginResponse{Status: RUNNING, Message: "started"}, true)
+	span.End()
+
+	ctx, span = tracer().Start(context.Background(), "template.execute")
+	tracker.update(ctx, "foo", "copy-a1b2c3d4e5f6", "test_service", &PluginResponse{Status: FAILED, Message: "job failed"}, true)
+	span.End()
+
+	type spanSummary struct {
+		Name        string
+		Events      int
+		Links       int
+		Status      codes.Code
+		NodeID      string
+		ExecutionID string
+	}
+
+	var got []spanSummary
+	var executionTraceID string
+	for _, s := range recorder.Ended() {
+		summary := spanSummary{
+			Name:   s.Name(),
+			Events: len(s.Events()),
+			Links:  len(s.Links()),
+			Status: s.Status().Code,
+		}
+		for _, attr := range s.Attributes() {
+			switch attr.Key {
+			case "awf.execution.trace_id":
+				summary.ExecutionID = attr.Value.AsString()
+			case "awf.node.id":
+				summary.NodeID = attr.Value.AsString()
+			}
+		}
+		if s.Name() == "aws.execution" {
+			executionTraceID = s.SpanContext().TraceID().String()
+		}
+		got = append(got, summary)
+	}
+
+	want := []spanSummary{
+		{Name: "template.execute", ExecutionID: executionTraceID},
+		{Name: "aws.execution", Events: 2, Links: 1, Status: codes.Error, NodeID: "copy-a1b2c3d4e5f6"},
+		{Name: "template.execute", ExecutionID: executionTraceID},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+}
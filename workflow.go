@@ -14,12 +14,55 @@
 
 package main
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // PluginWorkflow describes a workflow.
 type PluginWorkflow struct {
 	sync.Mutex
-	ID      string `json:"id,omitempty" xml:"id,omitempty" yaml:"id,omitempty"`
-	Status  string `json:"status,omitempty" xml:"status,omitempty" yaml:"status,omitempty"`
-	Message string `json:"message,omitempty" xml:"message,omitempty" yaml:"message,omitempty"`
+	ID        string            `json:"id,omitempty" xml:"id,omitempty" yaml:"id,omitempty"`
+	Status    string            `json:"status,omitempty" xml:"status,omitempty" yaml:"status,omitempty"`
+	Message   string            `json:"message,omitempty" xml:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt time.Time         `json:"started_at,omitempty" xml:"started_at,omitempty" yaml:"started_at,omitempty"`
+	Outputs   map[string]string `json:"outputs,omitempty" xml:"outputs,omitempty" yaml:"outputs,omitempty"`
+	// Express is set for the execution of AWS Step Functions Express state
+	// machine that runs in the background.
+	Express bool `json:"express,omitempty" xml:"express,omitempty" yaml:"express,omitempty"`
+	// mapProgress holds the progress of the Map states of AWS Step Functions
+	// execution counted from the history events read so far.
+	mapProgress *stepFunctionMapProgress
+	// s3Batch holds the progress of Amazon S3 operation over the objects
+	// under a prefix.
+	s3Batch *s3BatchProgress
+}
+
+// workflowKey returns the key of the workflow node tracked by the plugin.
+// The nodes of the same workflow run concurrently, so the plugin tracks the
+// nodes separately.
+func workflowKey(workflowID, nodeID string) string {
+	return workflowID + "/" + nodeID
+}
+
+// getWorkflow returns the workflow tracked for the node of the request.
+func (ex *ExecutorPlugin) getWorkflow(req *PluginRequest, workflowID string) (*PluginWorkflow, bool) {
+	ex.workflowsMu.Lock()
+	defer ex.workflowsMu.Unlock()
+	wf, exists := ex.Workflows[workflowKey(workflowID, req.nodeID)]
+	return wf, exists
+}
+
+// setWorkflow tracks the workflow for the node of the request.
+func (ex *ExecutorPlugin) setWorkflow(req *PluginRequest, workflowID string, wf *PluginWorkflow) {
+	ex.workflowsMu.Lock()
+	defer ex.workflowsMu.Unlock()
+	ex.Workflows[workflowKey(workflowID, req.nodeID)] = wf
+}
+
+// deleteWorkflow stops tracking the workflow for the node of the request.
+func (ex *ExecutorPlugin) deleteWorkflow(req *PluginRequest, workflowID string) {
+	ex.workflowsMu.Lock()
+	defer ex.workflowsMu.Unlock()
+	delete(ex.Workflows, workflowKey(workflowID, req.nodeID))
 }
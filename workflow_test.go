@@ -0,0 +1,47 @@
+// Copyright 2023 Paul Greenberg greenpau@outlook.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrackWorkflowNodes(t *testing.T) {
+	ex := &ExecutorPlugin{
+		Workflows: make(map[string]*PluginWorkflow),
+	}
+	copyReq := &PluginRequest{nodeID: "copy_s3_objects-af4623f9f560"}
+	tagReq := &PluginRequest{nodeID: "tag_s3_objects-84e34a85e754"}
+
+	ex.setWorkflow(copyReq, "27c01e7c", &PluginWorkflow{ID: "copy"})
+	ex.setWorkflow(tagReq, "27c01e7c", &PluginWorkflow{ID: "tag"})
+	ex.deleteWorkflow(copyReq, "27c01e7c")
+
+	got := make(map[string]string)
+	for _, req := range []*PluginRequest{copyReq, tagReq} {
+		if wf, exists := ex.getWorkflow(req, "27c01e7c"); exists {
+			got[req.nodeID] = wf.ID
+		}
+	}
+
+	want := map[string]string{
+		"tag_s3_objects-84e34a85e754": "tag",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected result (-want +got):\n%s", diff)
+	}
+}